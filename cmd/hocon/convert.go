@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"hocon-go/render"
+	"io"
+	"strings"
+)
+
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("convert", "--to json|yaml|properties [flags] FILE", stderr)
+	var load loadFlags
+	load.register(flags)
+	to := flags.String("to", "", "output format: json, yaml, properties or hocon")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 || *to == "" {
+		flags.Usage()
+		return 2
+	}
+	cfg, err := load.load(positional[0], stdin)
+	if err != nil {
+		reportError(stderr, "convert", err)
+		return 1
+	}
+	var text string
+	switch *to {
+	case "json", "hocon":
+		text, err = cfg.Render(render.Options{JSON: *to == "json", Formatted: true})
+		text = strings.TrimSuffix(text, "\n") + "\n"
+	case "yaml":
+		text, err = cfg.RenderYAML()
+	case "properties":
+		text, err = cfg.RenderProperties()
+	default:
+		fmt.Fprintf(stderr, "hocon convert: unknown format %q\n", *to)
+		return 2
+	}
+	if err != nil {
+		reportError(stderr, "convert", err)
+		return 1
+	}
+	fmt.Fprint(stdout, text)
+	return 0
+}
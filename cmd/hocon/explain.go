@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func runExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("explain", "[flags] FILE PATH", stderr)
+	load := loadFlags{trace: true}
+	load.register(flags)
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 2 {
+		flags.Usage()
+		return 2
+	}
+	cfg, err := load.load(positional[0], stdin)
+	if err != nil {
+		reportError(stderr, "explain", err)
+		return 1
+	}
+	explanation, err := cfg.Explain(positional[1])
+	if err != nil {
+		reportError(stderr, "explain", err)
+		return 1
+	}
+	fmt.Fprint(stdout, explanation)
+	return 0
+}
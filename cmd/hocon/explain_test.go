@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.conf", "port = 8080\nhost = localhost\n")
+	file := writeConfig(t, dir, "app.conf", "include \"base.conf\"\nurl = ${host}\":\"${port}\n")
+
+	out, errOut, code := runCommand(t, "", "explain", file, "port", "--set", "port=9090")
+	if code != 0 {
+		t.Fatalf("explain: code %d, stderr %q", code, errOut)
+	}
+	for _, want := range []string{"port = 9090\n", "base.conf:1:1 (included from " + file + "): port = 8080\n", "override:1:1: port = 9090\n"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("explain output misses %q:\n%s", want, out)
+		}
+	}
+
+	out, _, code = runCommand(t, "", "explain", file, "url")
+	if code != 0 || !strings.Contains(out, `${host} = "localhost", from host`) || !strings.Contains(out, `${port} = 8080, from port`) {
+		t.Fatalf("explain url: code %d, output:\n%s", code, out)
+	}
+
+	_, errOut, code = runCommand(t, "", "explain", file, "missing")
+	if code != 1 || !strings.Contains(errOut, "hocon explain:") {
+		t.Fatalf("explain missing: code %d, stderr %q", code, errOut)
+	}
+}
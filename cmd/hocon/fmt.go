@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"hocon-go/format"
+	"hocon-go/parser"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// hoconExtensions lists the file extensions picked up when formatting a directory.
+var hoconExtensions = []string{".conf", ".hocon"}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write the result back to the source file")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	indent := flags.String("indent", "  ", "indentation unit")
+	separator := flags.String("sep", "=", "key-value separator, '=' or ':'")
+	objectSeparator := flags.Bool("object-sep", false, "write the separator before object values")
+	commas := flags.String("commas", "none", "commas between fields: none, separate or trailing")
+	braces := flags.String("braces", "preserve", "root braces: preserve, omit or keep")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hocon fmt [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := format.Options{Indent: *indent, Separator: *separator, SeparatorBeforeObject: *objectSeparator}
+	if opts.Separator != "=" && opts.Separator != ":" {
+		fmt.Fprintf(stderr, "hocon fmt: invalid separator %q\n", opts.Separator)
+		return 2
+	}
+	switch *commas {
+	case "none":
+		opts.Commas = format.CommaNone
+	case "separate":
+		opts.Commas = format.CommaSeparate
+	case "trailing":
+		opts.Commas = format.CommaTrailing
+	default:
+		fmt.Fprintf(stderr, "hocon fmt: invalid comma style %q\n", *commas)
+		return 2
+	}
+	switch *braces {
+	case "preserve":
+		opts.RootBraces = format.BracesPreserve
+	case "omit":
+		opts.RootBraces = format.BracesOmit
+	case "keep":
+		opts.RootBraces = format.BracesKeep
+	default:
+		fmt.Fprintf(stderr, "hocon fmt: invalid brace style %q\n", *braces)
+		return 2
+	}
+
+	f := &fmtRun{opts: opts, list: *list, write: *write, diff: *diff, stdout: stdout, stderr: stderr}
+	if flags.NArg() == 0 {
+		if f.write {
+			fmt.Fprintln(stderr, "hocon fmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "hocon fmt: %v\n", err)
+			return 2
+		}
+		f.source("<standard input>", src, 0)
+		return f.exitCode
+	}
+	for _, path := range flags.Args() {
+		f.path(path)
+	}
+	return f.exitCode
+}
+
+type fmtRun struct {
+	opts              format.Options
+	list, write, diff bool
+	stdout, stderr    io.Writer
+	exitCode          int
+}
+
+func (f *fmtRun) report(err error) {
+	fmt.Fprintln(f.stderr, err)
+	f.exitCode = 2
+}
+
+func (f *fmtRun) path(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		f.report(err)
+		return
+	}
+	if !info.IsDir() {
+		f.file(path, info.Mode().Perm())
+		return
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isHoconFile(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f.file(p, info.Mode().Perm())
+		return nil
+	})
+	if err != nil {
+		f.report(err)
+	}
+}
+
+func isHoconFile(path string) bool {
+	ext := filepath.Ext(path)
+	for _, candidate := range hoconExtensions {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fmtRun) file(path string, perm fs.FileMode) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		f.report(err)
+		return
+	}
+	f.source(path, src, perm)
+}
+
+// source formats one document and reports it according to the flags. Without
+// -l, -w or -d the result is written to standard output.
+func (f *fmtRun) source(name string, src []byte, perm fs.FileMode) {
+	doc, err := parser.NewParser(src).WithFilename(name).ParseCST()
+	if err != nil {
+		var syntaxErr *parser.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = errors.New(syntaxErr.Verbose())
+		}
+		f.report(err)
+		return
+	}
+	out := []byte(format.Node(doc, f.opts))
+	changed := !bytes.Equal(src, out)
+	if f.list && changed {
+		fmt.Fprintln(f.stdout, name)
+	}
+	if f.write && changed {
+		if err := os.WriteFile(name, out, perm); err != nil {
+			f.report(err)
+			return
+		}
+	}
+	if f.diff && changed {
+		fmt.Fprint(f.stdout, unifiedDiff(name, string(src), string(out)))
+	}
+	if !f.list && !f.write && !f.diff {
+		f.stdout.Write(out)
+	}
+}
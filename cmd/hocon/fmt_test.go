@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestFmtFlags(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.conf")
+	clean := filepath.Join(dir, "clean.conf")
+	if err := os.WriteFile(messy, []byte("a:1,b {c=2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(clean, []byte("a = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, code := runCommand(t, "", "fmt", "-l", dir)
+	if code != 0 || out != messy+"\n" {
+		t.Fatalf("fmt -l: code %d, output %q", code, out)
+	}
+	out, _, code = runCommand(t, "", "fmt", "-d", messy)
+	if code != 0 || !strings.Contains(out, "-a:1,b {c=2}\n+a = 1\n+b {\n+  c = 2\n+}\n") {
+		t.Fatalf("fmt -d: code %d, output %q", code, out)
+	}
+	if _, _, code = runCommand(t, "", "fmt", "-w", messy); code != 0 {
+		t.Fatalf("fmt -w: code %d", code)
+	}
+	data, err := os.ReadFile(messy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a = 1\nb {\n  c = 2\n}\n" {
+		t.Fatalf("fmt -w wrote %q", data)
+	}
+	out, _, code = runCommand(t, "", "fmt", "-l", dir)
+	if code != 0 || out != "" {
+		t.Fatalf("fmt -l after -w: code %d, output %q", code, out)
+	}
+}
+
+func TestFmtStdin(t *testing.T) {
+	out, _, code := runCommand(t, "a:1", "fmt", "-sep", ":", "-braces", "keep")
+	if code != 0 || out != "{\n  a: 1\n}\n" {
+		t.Fatalf("code %d, output %q", code, out)
+	}
+	_, errOut, code := runCommand(t, "a = [1,\n", "fmt")
+	if code != 2 || !strings.Contains(errOut, "<standard input>:2:1: expected ']'") {
+		t.Fatalf("code %d, stderr %q", code, errOut)
+	}
+}
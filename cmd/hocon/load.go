@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hocon-go/config"
+	"hocon-go/parser"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// listFlag collects the values of a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// loadFlags are the flags shared by the commands that load a configuration.
+type loadFlags struct {
+	classpath listFlag
+	env       bool
+	envPrefix string
+	set       listFlag
+	// trace is set by commands that explain values rather than by a flag.
+	trace bool
+}
+
+func (l *loadFlags) register(flags *flag.FlagSet) {
+	flags.Var(&l.classpath, "classpath", "directory searched by classpath includes; repeatable or a path list")
+	flags.BoolVar(&l.env, "env", false, "resolve substitutions from environment variables")
+	flags.Var(&l.set, "set", "override a setting with path=value; repeatable")
+	flags.StringVar(&l.envPrefix, "env-prefix", "", "override settings from environment variables with this prefix, e.g. CONFIG_FORCE_")
+}
+
+func (l *loadFlags) options() *parser.ConfigOptions {
+	opts := parser.DefaultConfigOptions()
+	for _, entry := range l.classpath {
+		opts.Classpath = append(opts.Classpath, filepath.SplitList(entry)...)
+	}
+	opts.UseSystemEnvironment = l.env
+	opts.Overrides = l.set
+	opts.EnvOverridePrefix = l.envPrefix
+	opts.Trace = l.trace
+	return &opts
+}
+
+// load parses the file at path, or standard input for "-".
+func (l *loadFlags) load(path string, stdin io.Reader) (*config.Config, error) {
+	if path == "-" {
+		return config.ParseReader(stdin, l.options())
+	}
+	return config.ParseFile(path, l.options())
+}
+
+// parseFlags parses flags that may appear before, between or after the
+// positional arguments, which it returns.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name, usage string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: hocon %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// reportError prints err, with the offending source line for syntax errors.
+func reportError(stderr io.Writer, command string, err error) {
+	var syntaxErr *parser.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		fmt.Fprintf(stderr, "hocon %s: %s\n", command, syntaxErr.Verbose())
+		return
+	}
+	fmt.Fprintf(stderr, "hocon %s: %v\n", command, err)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"hocon-go/lsp"
+	"io"
+)
+
+func runLSP(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("lsp", "[flags]", stderr)
+	var load loadFlags
+	load.register(flags)
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		flags.Usage()
+		return 2
+	}
+	if err := lsp.NewServer(stdin, stdout, *load.options()).Serve(); err != nil {
+		fmt.Fprintf(stderr, "hocon lsp: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+// Command hocon works with HOCON configuration files.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command is a subcommand of hocon. run returns the process exit code.
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"resolve":  {summary: "print the resolved configuration", run: runResolve},
+	"get":      {summary: "print the value at a path", run: runGet},
+	"validate": {summary: "check that a configuration parses and resolves", run: runValidate},
+	"convert":  {summary: "convert a configuration to JSON, YAML or properties", run: runConvert},
+	"fmt":      {summary: "reformat HOCON files", run: runFmt},
+	"explain":  {summary: "show where the value at a path came from", run: runExplain},
+	"lsp":      {summary: "run a language server for HOCON files on stdio", run: runLSP},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "hocon: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	return cmd.run(args[1:], stdin, stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hocon <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
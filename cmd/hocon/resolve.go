@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/config"
+	"hocon-go/render"
+	"io"
+	"strings"
+)
+
+func runResolve(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("resolve", "[flags] FILE", stderr)
+	var load loadFlags
+	load.register(flags)
+	outputFormat := flags.String("format", "json", "output format: json or hocon")
+	origins := flags.Bool("origins", false, "annotate HOCON output with the origin of each value")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return 2
+	}
+	var opts render.Options
+	switch *outputFormat {
+	case "json":
+		opts = render.Options{JSON: true, Formatted: true}
+	case "hocon":
+		opts = render.Options{Formatted: true, OriginComments: *origins}
+	default:
+		fmt.Fprintf(stderr, "hocon resolve: unknown format %q\n", *outputFormat)
+		return 2
+	}
+	cfg, err := load.load(positional[0], stdin)
+	if err != nil {
+		reportError(stderr, "resolve", err)
+		return 1
+	}
+	text, err := cfg.Render(opts)
+	if err != nil {
+		reportError(stderr, "resolve", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, strings.TrimSuffix(text, "\n"))
+	return 0
+}
+
+func runGet(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("get", "[flags] FILE PATH", stderr)
+	var load loadFlags
+	load.register(flags)
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 2 {
+		flags.Usage()
+		return 2
+	}
+	cfg, err := load.load(positional[0], stdin)
+	if err != nil {
+		reportError(stderr, "get", err)
+		return 1
+	}
+	value, err := cfg.Get(positional[1])
+	if err != nil {
+		reportError(stderr, "get", err)
+		return 1
+	}
+	// Strings are printed bare so the output can be used directly in scripts.
+	if s, ok := value.(string); ok {
+		fmt.Fprintln(stdout, s)
+		return 0
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		reportError(stderr, "get", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(data))
+	return 0
+}
+
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("validate", "[flags] FILE", stderr)
+	var load loadFlags
+	load.register(flags)
+	reference := flags.String("reference", "", "also check the configuration against the paths and types of this file")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return 2
+	}
+	cfg, err := load.load(positional[0], stdin)
+	if err == nil {
+		_, err = cfg.Resolve()
+	}
+	if err == nil && *reference != "" {
+		var ref *config.Config
+		if ref, err = load.load(*reference, stdin); err == nil {
+			err = cfg.CheckValid(ref)
+		}
+	}
+	var failed *common.ValidationFailed
+	if errors.As(err, &failed) {
+		for _, problem := range failed.Problems {
+			fmt.Fprintf(stderr, "hocon validate: %v\n", problem)
+		}
+		return 1
+	}
+	if err != nil {
+		reportError(stderr, "validate", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveGetValidate(t *testing.T) {
+	dir := t.TempDir()
+	lib := t.TempDir()
+	writeConfig(t, lib, "defaults.conf", "timeout = 5s\n")
+	file := writeConfig(t, dir, "app.conf", `include classpath("defaults.conf")
+app { name = demo, port = 8080, hosts = [a, b] }
+home = ${HOCON_CLI_TEST_HOME}
+`)
+	t.Setenv("HOCON_CLI_TEST_HOME", "/home/demo")
+
+	out, errOut, code := runCommand(t, "", "resolve", file, "--classpath", lib, "--env", "--set", "app.port=9090")
+	if code != 0 {
+		t.Fatalf("resolve: code %d, stderr %q", code, errOut)
+	}
+	for _, want := range []string{`"port": 9090`, `"timeout": "5s"`, `"home": "/home/demo"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("resolve output misses %s:\n%s", want, out)
+		}
+	}
+
+	out, _, code = runCommand(t, "", "get", "--classpath", lib, "--env", file, "app.name")
+	if code != 0 || out != "demo\n" {
+		t.Fatalf("get string: code %d, output %q", code, out)
+	}
+	t.Setenv("HOCON_CLI_FORCE_app_name", "forced")
+	out, _, code = runCommand(t, "", "get", "--classpath", lib, "--env", "--env-prefix", "HOCON_CLI_FORCE_", file, "app.name")
+	if code != 0 || out != "forced\n" {
+		t.Fatalf("get with env override: code %d, output %q", code, out)
+	}
+	out, _, code = runCommand(t, "", "get", "--classpath", lib, "--env", file, "app.hosts")
+	if code != 0 || out != "[\n  \"a\",\n  \"b\"\n]\n" {
+		t.Fatalf("get array: code %d, output %q", code, out)
+	}
+	_, errOut, code = runCommand(t, "", "get", "--classpath", lib, "--env", file, "app.missing")
+	if code != 1 || !strings.Contains(errOut, "app.missing") {
+		t.Fatalf("get missing: code %d, stderr %q", code, errOut)
+	}
+
+	if _, errOut, code = runCommand(t, "", "validate", "--classpath", lib, "--env", file); code != 0 {
+		t.Fatalf("validate: code %d, stderr %q", code, errOut)
+	}
+	if _, errOut, code = runCommand(t, "", "validate", file); code != 1 || errOut == "" {
+		t.Fatalf("validate without --env: code %d, stderr %q", code, errOut)
+	}
+	if _, errOut, code = runCommand(t, "a {", "validate", "-"); code != 1 || !strings.Contains(errOut, "expected") {
+		t.Fatalf("validate syntax error: code %d, stderr %q", code, errOut)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	input := `a { b = 1, c = [x, "yes", {d = 2.5}], e = "with space", f = null }
+`
+	out, _, code := runCommand(t, input, "convert", "--to", "yaml", "-")
+	expected := `a:
+  b: 1
+  c:
+    - x
+    - "yes"
+    - d: 2.5
+  e: with space
+  f: null
+`
+	if code != 0 || out != expected {
+		t.Fatalf("yaml: code %d, output\n%s", code, out)
+	}
+	out, _, code = runCommand(t, input, "convert", "--to", "properties", "-")
+	expected = "a.b=1\na.c.0=x\na.c.1=yes\na.c.2.d=2.5\na.e=with space\n"
+	if code != 0 || out != expected {
+		t.Fatalf("properties: code %d, output\n%s", code, out)
+	}
+	out, _, code = runCommand(t, input, "convert", "--to", "json", "-")
+	if code != 0 || !strings.HasPrefix(out, "{\n  \"a\": {\n    \"b\": 1,") {
+		t.Fatalf("json: code %d, output\n%s", code, out)
+	}
+	if _, _, code = runCommand(t, input, "convert", "--to", "toml", "-"); code != 2 {
+		t.Fatalf("unknown format: code %d", code)
+	}
+}
+
+func TestValidateReference(t *testing.T) {
+	dir := t.TempDir()
+	reference := writeConfig(t, dir, "reference.conf", "app { port = 8080, name = demo }\n")
+	good := writeConfig(t, dir, "good.conf", "app { port = 9090, name = x }\n")
+	bad := writeConfig(t, dir, "bad.conf", "app {\n  port = [1]\n  nmae = x\n}\n")
+
+	if _, errOut, code := runCommand(t, "", "validate", "--reference", reference, good); code != 0 {
+		t.Fatalf("validate good: code %d, stderr %q", code, errOut)
+	}
+	_, errOut, code := runCommand(t, "", "validate", "--reference", reference, bad)
+	if code != 1 {
+		t.Fatalf("validate bad: code %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(errOut), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "bad.conf:2:10: app.port has type array rather than number") ||
+		!strings.Contains(lines[1], "app.name") || !strings.Contains(lines[2], "bad.conf:3:10: invalid value at app.nmae") {
+		t.Fatalf("validate bad: stderr %q", errOut)
+	}
+}
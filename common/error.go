@@ -1,38 +1,186 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// Category classifies errors in the manner of the subclasses of Lightbend's
+// ConfigException, so that callers can branch on the kind of failure rather
+// than on message text. Every error type of this module reports its category;
+// CategoryOf finds it anywhere in a chain of wrapped errors. The types listed
+// with each category can also be picked out with errors.As.
+type Category int
+
+const (
+	// CategoryOther is reported for errors outside the taxonomy.
+	CategoryOther Category = iota
+	// CategoryParse: malformed input, reported as *parser.SyntaxError.
+	CategoryParse
+	// CategoryIO: a document could not be read, reported as *IOError.
+	CategoryIO
+	// CategoryMissing: a path has no value, reported as *PathNotFound.
+	CategoryMissing
+	// CategoryNull: a path is set to null where a value is required,
+	// reported as *Null.
+	CategoryNull
+	// CategoryWrongType: a value has an unexpected type, reported as
+	// *WrongType or *ConcatenateDifferentType.
+	CategoryWrongType
+	// CategoryBadValue: a value has the right type but cannot be used,
+	// reported as *BadValue.
+	CategoryBadValue
+	// CategoryUnresolvedSubstitution: a substitution names a path that is not
+	// set, reported as *SubstitutionNotFound.
+	CategoryUnresolvedSubstitution
+	// CategoryCycle: substitutions refer to each other in a loop, reported as
+	// *SubstitutionCycle or *SubstitutionDepthExceeded.
+	CategoryCycle
+	// CategoryIncludeNotFound: a required include is missing, reported as
+	// *IncludeNotFound.
+	CategoryIncludeNotFound
+	// CategoryValidation: a config does not match its reference, reported as
+	// *ValidationFailed.
+	CategoryValidation
+)
+
+var categoryNames = [...]string{
+	CategoryOther:                  "other",
+	CategoryParse:                  "parse",
+	CategoryIO:                     "io",
+	CategoryMissing:                "missing",
+	CategoryNull:                   "null",
+	CategoryWrongType:              "wrong type",
+	CategoryBadValue:               "bad value",
+	CategoryUnresolvedSubstitution: "unresolved substitution",
+	CategoryCycle:                  "cycle",
+	CategoryIncludeNotFound:        "include not found",
+	CategoryValidation:             "validation",
+}
+
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return fmt.Sprintf("Category(%d)", int(c))
+	}
+	return categoryNames[c]
+}
+
+// Error is implemented by every error type of the taxonomy.
+type Error interface {
+	error
+	Category() Category
+}
+
+// CategoryOf returns the category of the first error in err's chain that has
+// one, or CategoryOther.
+func CategoryOf(err error) Category {
+	var categorized Error
+	if errors.As(err, &categorized) {
+		return categorized.Category()
+	}
+	return CategoryOther
+}
+
+// withOrigin prefixes msg with origin when it is known.
+func withOrigin(origin *Origin, msg string) string {
+	if origin == nil {
+		return msg
+	}
+	return origin.String() + ": " + msg
+}
+
+// IOError reports a document that could not be read. Resource is the file or
+// URL; Origin is the include statement that named it, when there is one.
+type IOError struct {
+	Resource string
+	Origin   *Origin
+	Err      error
+}
+
+func (e *IOError) Error() string {
+	msg := e.Err.Error()
+	if !strings.Contains(msg, e.Resource) {
+		msg = e.Resource + ": " + msg
+	}
+	return withOrigin(e.Origin, msg)
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+func (*IOError) Category() Category {
+	return CategoryIO
+}
+
+// IncludeNotFound reports a required include whose document does not exist.
+// Include is the include statement, Origin its position. Err wraps
+// fs.ErrNotExist.
+type IncludeNotFound struct {
+	Include string
+	Origin  *Origin
+	Err     error
+}
+
+func (e *IncludeNotFound) Error() string {
+	return withOrigin(e.Origin, fmt.Sprintf("%s: %v", e.Include, e.Err))
+}
+
+func (e *IncludeNotFound) Unwrap() error {
+	return e.Err
+}
+
+func (*IncludeNotFound) Category() Category {
+	return CategoryIncludeNotFound
+}
+
 type ConcatenateDifferentType struct {
 	Path      string
 	LeftType  string
 	RightType string
+	// Origin is where the concatenation was written, when known.
+	Origin *Origin
 }
 
 func (c *ConcatenateDifferentType) Error() string {
-	return fmt.Sprintf("cannot concatenate different type %s and %s at %s", c.LeftType, c.RightType, c.Path)
+	return withOrigin(c.Origin, fmt.Sprintf("cannot concatenate different type %s and %s at %s", c.LeftType, c.RightType, c.Path))
+}
+
+func (*ConcatenateDifferentType) Category() Category {
+	return CategoryWrongType
 }
 
 type SubstitutionNotFound struct {
 	Path string
+	// Origin is where the substitution was written, when known.
+	Origin *Origin
 }
 
 func (e *SubstitutionNotFound) Error() string {
-	return fmt.Sprintf("substitution %s not found", e.Path)
+	return withOrigin(e.Origin, fmt.Sprintf("substitution %s not found", e.Path))
+}
+
+func (*SubstitutionNotFound) Category() Category {
+	return CategoryUnresolvedSubstitution
 }
 
 type SubstitutionCycle struct {
 	Current   string
 	Backtrace []string
+	// Origin is where the substitution closing the cycle was written, when known.
+	Origin *Origin
 }
 
 func (e *SubstitutionCycle) Error() string {
 	if len(e.Backtrace) == 0 {
-		return fmt.Sprintf("substitution cycle detected at %s", e.Current)
+		return withOrigin(e.Origin, fmt.Sprintf("substitution cycle detected at %s", e.Current))
 	}
-	return fmt.Sprintf("substitution cycle: %s -> %s (cycle closed)", strings.Join(e.Backtrace, " -> "), e.Current)
+	return withOrigin(e.Origin, fmt.Sprintf("substitution cycle: %s -> %s (cycle closed)", strings.Join(e.Backtrace, " -> "), e.Current))
+}
+
+func (*SubstitutionCycle) Category() Category {
+	return CategoryCycle
 }
 
 type SubstitutionDepthExceeded struct {
@@ -42,3 +190,100 @@ type SubstitutionDepthExceeded struct {
 func (e *SubstitutionDepthExceeded) Error() string {
 	return fmt.Sprintf("substitution depth exceeded the limit of %d levels", e.MaxDepth)
 }
+
+func (*SubstitutionDepthExceeded) Category() Category {
+	return CategoryCycle
+}
+
+type PathNotFound struct {
+	Path string
+	// Origin is where the object that lacks the path was defined, when known.
+	Origin *Origin
+}
+
+func (e *PathNotFound) Error() string {
+	return withOrigin(e.Origin, fmt.Sprintf("no configuration setting found for path %s", e.Path))
+}
+
+func (*PathNotFound) Category() Category {
+	return CategoryMissing
+}
+
+// Null reports a path that is set to null where a value of type Expected is
+// required.
+type Null struct {
+	Path     string
+	Expected string
+	Origin   *Origin
+}
+
+func (e *Null) Error() string {
+	return withOrigin(e.Origin, fmt.Sprintf("%s is null rather than %s", e.Path, e.Expected))
+}
+
+func (*Null) Category() Category {
+	return CategoryNull
+}
+
+type WrongType struct {
+	Path     string
+	Expected string
+	Actual   string
+	// Origin is where the value was defined, when known.
+	Origin *Origin
+}
+
+func (e *WrongType) Error() string {
+	return withOrigin(e.Origin, fmt.Sprintf("%s has type %s rather than %s", e.Path, e.Actual, e.Expected))
+}
+
+func (*WrongType) Category() Category {
+	return CategoryWrongType
+}
+
+type BadValue struct {
+	Path   string
+	Reason string
+	// Origin is where the value was defined, when known.
+	Origin *Origin
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *BadValue) Error() string {
+	if e.Path == "" {
+		return withOrigin(e.Origin, "invalid value: "+e.Reason)
+	}
+	return withOrigin(e.Origin, fmt.Sprintf("invalid value at %s: %s", e.Path, e.Reason))
+}
+
+func (e *BadValue) Unwrap() error {
+	return e.Err
+}
+
+func (*BadValue) Category() Category {
+	return CategoryBadValue
+}
+
+// ValidationFailed reports every problem found when checking a config against
+// a reference. Each problem is one of the errors of this package, such as a
+// *PathNotFound or a *WrongType; errors.As finds the first of a given type.
+type ValidationFailed struct {
+	Problems []error
+}
+
+func (e *ValidationFailed) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		msgs[i] = problem.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationFailed) Unwrap() []error {
+	return e.Problems
+}
+
+func (*ValidationFailed) Category() Category {
+	return CategoryValidation
+}
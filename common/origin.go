@@ -0,0 +1,58 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Origin records where a value was defined.
+type Origin struct {
+	// File is the file name or URL the value was read from. It is empty for
+	// documents parsed from memory.
+	File string
+	// Includes lists the files that included File, outermost first.
+	Includes []string
+	// Line and Column are 1-based; Column counts bytes. Both are zero when the
+	// position within the file is unknown, e.g. for values read from JSON.
+	Line   int
+	Column int
+	// Offset is the 0-based byte offset into the file.
+	Offset int
+}
+
+func (o *Origin) String() string {
+	if o == nil {
+		return "unknown origin"
+	}
+	file := o.File
+	if file == "" {
+		file = "<input>"
+	}
+	var location string
+	if o.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", file, o.Line, o.Column)
+	} else {
+		location = file
+	}
+	if len(o.Includes) == 0 {
+		return location
+	}
+	chain := make([]string, len(o.Includes))
+	for i, inc := range o.Includes {
+		chain[len(chain)-1-i] = inc
+	}
+	return fmt.Sprintf("%s (included from %s)", location, strings.Join(chain, " <- "))
+}
+
+// Located is embedded by values that remember their Origin.
+type Located struct {
+	origin *Origin
+}
+
+func (l *Located) Origin() *Origin {
+	return l.origin
+}
+
+func (l *Located) SetOrigin(origin *Origin) {
+	l.origin = origin
+}
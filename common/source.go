@@ -0,0 +1,24 @@
+package common
+
+import "os"
+
+// SubstitutionSource supplies values for substitutions that cannot be resolved
+// from the configuration itself, such as environment variables.
+type SubstitutionSource interface {
+	Lookup(path string) (string, bool)
+}
+
+// MapSource is a SubstitutionSource backed by a map keyed by substitution path.
+type MapSource map[string]string
+
+func (m MapSource) Lookup(path string) (string, bool) {
+	val, ok := m[path]
+	return val, ok
+}
+
+// EnvSource resolves substitutions from the process environment.
+type EnvSource struct{}
+
+func (EnvSource) Lookup(path string) (string, bool) {
+	return os.LookupEnv(path)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"reflect"
+	"testing"
+)
+
+func TestConcatenation(t *testing.T) {
+	cases := []struct {
+		name     string
+		conf     string
+		expected map[string]interface{}
+	}{
+		{
+			name: "objects across substitutions",
+			conf: "base { x = 1 }\na = ${base} { y = 2 } ${extra}\nextra { z = 3 }",
+			expected: map[string]interface{}{
+				"base":  map[string]interface{}{"x": int64(1)},
+				"extra": map[string]interface{}{"z": int64(3)},
+				"a":     map[string]interface{}{"x": int64(1), "y": int64(2), "z": int64(3)},
+			},
+		},
+		{
+			name: "arrays across substitutions",
+			conf: "a = [1]\nb = ${a} [2] ${a}\nb = [0] ${b}",
+			expected: map[string]interface{}{
+				"a": []interface{}{int64(1)},
+				"b": []interface{}{int64(0), int64(1), int64(2), int64(1)},
+			},
+		},
+		{
+			name: "whitespace between simple values",
+			conf: "n = 3\na = ${n}  items\nb = [1]   [2]\nc = ${?missing} x ${?missing}",
+			expected: map[string]interface{}{
+				"n": int64(3),
+				"a": "3  items",
+				"b": []interface{}{int64(1), int64(2)},
+				"c": " x ",
+			},
+		},
+		{
+			name: "path into a concatenation",
+			conf: "a = ${b.c}, b = {c = 1} {d = 2}",
+			expected: map[string]interface{}{
+				"a": int64(1),
+				"b": map[string]interface{}{"c": int64(1), "d": int64(2)},
+			},
+		},
+		{
+			name: "path into a substitution",
+			conf: "a = ${b.c}, b = ${x}, x = {c = 1}",
+			expected: map[string]interface{}{
+				"a": int64(1),
+				"b": map[string]interface{}{"c": int64(1)},
+				"x": map[string]interface{}{"c": int64(1)},
+			},
+		},
+		{
+			name: "missing optional values",
+			conf: "a = ${?missing}\nb = [1, ${?missing}, 2]\nc = [1] ${?missing}\nd { e = ${?missing} }",
+			expected: map[string]interface{}{
+				"b": []interface{}{int64(1), int64(2)},
+				"c": []interface{}{int64(1)},
+				"d": map[string]interface{}{},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseString(tc.conf, nil)
+			if err != nil {
+				t.Fatalf("ParseString: %v", err)
+			}
+			actual, err := cfg.Resolve()
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("unexpected result %v", actual)
+			}
+		})
+	}
+}
+
+func TestConcatenationErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		conf   string
+		left   string
+		right  string
+		line   int
+		column int
+	}{
+		{"array and string", "x = 1\na = [1] foo", "array", "string", 2, 5},
+		{"string and array", "x = 1\na = foo [1]", "string", "array", 2, 5},
+		{"object and number through substitution", "o { k = v }\nn = 1\na = ${o} ${n}", "object", "number", 3, 5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseString(tc.conf, nil)
+			if err != nil {
+				t.Fatalf("ParseString: %v", err)
+			}
+			_, err = cfg.Resolve()
+			var mixErr *common.ConcatenateDifferentType
+			if !errors.As(err, &mixErr) {
+				t.Fatalf("expected ConcatenateDifferentType, got %v", err)
+			}
+			if mixErr.Path != "a" || mixErr.LeftType != tc.left || mixErr.RightType != tc.right {
+				t.Fatalf("unexpected error %+v", mixErr)
+			}
+			if mixErr.Origin == nil || mixErr.Origin.Line != tc.line || mixErr.Origin.Column != tc.column {
+				t.Fatalf("unexpected position %v", mixErr.Origin)
+			}
+		})
+	}
+}
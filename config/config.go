@@ -1,18 +1,29 @@
 package config
 
 import (
-	"bytes"
-	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
 	"hocon-go/parser"
 	"hocon-go/raw"
 	"io"
-	"net/http"
+	"io/fs"
+	"sync"
 )
 
-// Config represents a parsed HOCON document before resolution.
+// Config represents a parsed HOCON document. The document is resolved lazily
+// the first time one of the getters needs it, and the result is cached.
 type Config struct {
 	rawObj *raw.Object
 	opts   parser.ConfigOptions
+	// layer marks a Config without options of its own, such as the result of
+	// ParseOverrides, which takes them from its fallback.
+	layer bool
+
+	once sync.Once
+	root *merge.Object
+	err  error
+	// trace is recorded during resolution when opts.Trace is set.
+	trace *merge.Trace
 }
 
 // ParseFile reads the file at path and returns a Config.
@@ -22,6 +33,42 @@ func ParseFile(path string, opts *parser.ConfigOptions) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if obj, err = applyLayers(obj, options); err != nil {
+		return nil, err
+	}
+	return &Config{rawObj: obj, opts: options}, nil
+}
+
+// ParseFS parses the file name in fsys, trying the .conf, .json and
+// .properties extensions when name has none. Plain includes in the file are looked up in fsys first,
+// so a tree of defaults embed with go:embed loads as it would from disk.
+func ParseFS(fsys fs.FS, name string, opts *parser.ConfigOptions) (*Config, error) {
+	options := normalizeOptions(opts)
+	obj, err := parser.ParseFS(fsys, name, options)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = applyLayers(obj, options); err != nil {
+		return nil, err
+	}
+	return &Config{rawObj: obj, opts: options}, nil
+}
+
+// ParseProperties reads a Java properties document from r. Dotted keys become
+// nested objects and all values are strings.
+func ParseProperties(r io.Reader, opts *parser.ConfigOptions) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &common.IOError{Err: err}
+	}
+	options := normalizeOptions(opts)
+	obj, err := parser.ParseProperties(data, "")
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = applyLayers(obj, options); err != nil {
+		return nil, err
+	}
 	return &Config{rawObj: obj, opts: options}, nil
 }
 
@@ -29,7 +76,7 @@ func ParseFile(path string, opts *parser.ConfigOptions) (*Config, error) {
 func ParseReader(r io.Reader, opts *parser.ConfigOptions) (*Config, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
-		return nil, err
+		return nil, &common.IOError{Err: err}
 	}
 	return parseBytes(data, opts)
 }
@@ -47,40 +94,48 @@ func parseBytes(data []byte, opts *parser.ConfigOptions) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if obj, err = applyLayers(obj, options); err != nil {
+		return nil, err
+	}
 	return &Config{rawObj: obj, opts: options}, nil
 }
 
-// ParseURL downloads the resource located at url and parses it as HOCON.
+// ParseURL fetches the document at url with opts.Fetcher, or the default
+// HTTP fetcher, and parses it. The content type selects HOCON or JSON, and
+// relative includes in the document are resolved against its URL.
 func ParseURL(url string, opts *parser.ConfigOptions) (*Config, error) {
-	resp, err := http.Get(url)
+	options := normalizeOptions(opts)
+	obj, err := parser.ParseURL(url, options)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	if obj, err = applyLayers(obj, options); err != nil {
+		return nil, err
 	}
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, resp.Body); err != nil {
+	return &Config{rawObj: obj, opts: options}, nil
+}
+
+// FromRaw returns a Config for a document that was already parsed, e.g. with
+// parser.Parser.ParseWithDiagnostics. The override layers selected by opts are
+// applied on top of it.
+func FromRaw(obj *raw.Object, opts *parser.ConfigOptions) (*Config, error) {
+	options := normalizeOptions(opts)
+	obj, err := applyLayers(obj, options)
+	if err != nil {
 		return nil, err
 	}
-	return parseBytes(buf.Bytes(), opts)
+	return &Config{rawObj: obj, opts: options}, nil
 }
 
 // Resolve converts the configuration into regular Go values (maps, slices, scalars).
 func (c *Config) Resolve() (map[string]interface{}, error) {
-	if c == nil || c.rawObj == nil {
+	if c == nil {
 		return map[string]interface{}{}, nil
 	}
-	obj, err := buildMergeObject(nil, c.rawObj)
+	obj, err := c.resolved()
 	if err != nil {
 		return nil, err
 	}
-	if err := obj.Substitute(); err != nil {
-		return nil, err
-	}
-	obj.ResolveAddAssign()
-	obj.TryBecomeMerged()
 	res, err := objectToInterface(obj)
 	if err != nil {
 		return nil, err
@@ -88,6 +143,36 @@ func (c *Config) Resolve() (map[string]interface{}, error) {
 	return res, nil
 }
 
+// resolved returns the resolved object tree, resolving the document on first use.
+func (c *Config) resolved() (*merge.Object, error) {
+	c.once.Do(func() {
+		if c.root != nil {
+			return
+		}
+		if c.opts.Trace {
+			c.trace = &merge.Trace{}
+		}
+		c.root, c.err = resolveObject(c.rawObj, c.opts, c.trace)
+	})
+	return c.root, c.err
+}
+
+func resolveObject(rawObj *raw.Object, opts parser.ConfigOptions, trace *merge.Trace) (*merge.Object, error) {
+	if rawObj == nil {
+		return merge.NewObject(make(map[string]merge.Value), true), nil
+	}
+	obj, err := buildMergeObject(nil, nil, rawObj, trace)
+	if err != nil {
+		return nil, err
+	}
+	if err := obj.Substitute(merge.ResolveOptions{Sources: opts.ResolveSources(), Trace: trace}); err != nil {
+		return nil, err
+	}
+	obj.ResolveAddAssign()
+	obj.TryBecomeMerged()
+	return obj, nil
+}
+
 // MustResolve resolves the configuration and panics on failure.
 func (c *Config) MustResolve() map[string]interface{} {
 	res, err := c.Resolve()
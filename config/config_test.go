@@ -20,28 +20,24 @@ func TestConfigGoldenFiles(t *testing.T) {
 	tests := []struct {
 		name     string
 		expected string
-		skip     bool
 	}{
-		{"empty", "empty.json", false},
-		{"add_assign", "add_assign_expected.json", false},
-		{"concat", "concat.json", false},
-		{"concat2", "concat2.json", false},
-		{"comment", "comment.json", false},
-		{"substitution", "substitution.json", false},
-		{"base", "base.json", true},       // TODO: path segments with leading whitespace.
-		{"concat3", "concat3.json", true}, // TODO: complex concatenation semantics.
-		{"concat4", "concat4.json", true},
-		{"concat5", "concat5.json", true},
-		{"include", "include.json", true}, // TODO: relative substitutions in included files.
-		{"substitution3", "substitution3.json", true},
-		{"self_referential", "self_referential.json", true},
+		{"empty", "empty.json"},
+		{"add_assign", "add_assign_expected.json"},
+		{"concat", "concat.json"},
+		{"concat2", "concat2.json"},
+		{"comment", "comment.json"},
+		{"substitution", "substitution.json"},
+		{"base", "base.json"},
+		{"concat3", "concat3.json"},
+		{"concat4", "concat4.json"},
+		{"concat5", "concat5.json"},
+		{"include", "include.json"},
+		{"substitution3", "substitution3.json"},
+		{"self_referential", "self_referential.json"},
 	}
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			if tc.skip {
-				t.Skip("pending implementation parity")
-			}
 			confPath := filepath.Join(resBase, tc.name+".conf")
 			actual, err := Load(confPath, opts)
 			if err != nil {
@@ -85,7 +81,15 @@ func TestConfigErrors(t *testing.T) {
 	})
 
 	t.Run("substitution_not_found", func(t *testing.T) {
-		t.Skip("relative substitution resolution pending")
+		cfg, err := ParseString(`a { include "`+filepath.Join(resBase, "foo.conf")+`" }, b = ${a.z}`, opts)
+		if err != nil {
+			t.Fatalf("ParseString: %v", err)
+		}
+		_, err = cfg.Resolve()
+		var notFound *common.SubstitutionNotFound
+		if !errors.As(err, &notFound) || notFound.Path != "a.z" {
+			t.Fatalf("expected substitution not found error, got %v", err)
+		}
 	})
 }
 
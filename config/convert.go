@@ -6,27 +6,32 @@ import (
 	"hocon-go/merge"
 	"hocon-go/raw"
 	"math"
-	"sort"
 )
 
-func buildMergeObject(parent *common.Path, obj *raw.Object) (*merge.Object, error) {
+// buildMergeObject converts obj, located at parent, into a merge object. mount
+// is the path the enclosing included file is mounted at, or nil outside
+// included files; substitutions in an included file are looked up relative to
+// it first. The fields are recorded in trace, which may be nil.
+func buildMergeObject(parent, mount *common.Path, obj *raw.Object, trace *merge.Trace) (*merge.Object, error) {
 	if obj == nil {
 		return merge.NewObject(make(map[string]merge.Value), true), nil
 	}
 	result := merge.NewObject(make(map[string]merge.Value), false)
+	result.SetOrigin(obj.Origin())
 	for _, field := range obj.Fields {
 		switch f := field.(type) {
 		case *raw.KeyValueField:
 			parts := f.Key.AsPath()
 			if len(parts) == 0 {
-				return nil, fmt.Errorf("object key is empty")
+				return nil, &common.BadValue{Path: displayPathOf(parent), Reason: "object key is empty", Origin: f.Origin()}
 			}
 			fullPath := appendPathParts(parent, parts)
-			val, err := valueFromRaw(fullPath, f.Value)
+			trace.Define(fullPath, f.Value, f.Origin())
+			val, err := valueFromRaw(fullPath, mount, f.Value, trace)
 			if err != nil {
 				return nil, err
 			}
-			fieldObj := objectForPath(parts, val)
+			fieldObj := objectForPath(parts, val, f.Origin())
 			if err := result.Merge(fieldObj, parent); err != nil {
 				return nil, err
 			}
@@ -34,7 +39,7 @@ func buildMergeObject(parent *common.Path, obj *raw.Object) (*merge.Object, erro
 			if f.Inclusion.Val == nil {
 				continue
 			}
-			child, err := buildMergeObject(parent, f.Inclusion.Val)
+			child, err := buildMergeObject(parent, parent, f.Inclusion.Val, trace)
 			if err != nil {
 				return nil, err
 			}
@@ -44,32 +49,48 @@ func buildMergeObject(parent *common.Path, obj *raw.Object) (*merge.Object, erro
 		case *raw.NewlineCommentField:
 			continue
 		default:
-			return nil, fmt.Errorf("unsupported object field %T", f)
+			return nil, &common.BadValue{Path: displayPathOf(parent), Reason: fmt.Sprintf("unsupported object field %T", f)}
 		}
 	}
 	return result, nil
 }
 
-func objectForPath(parts []string, value merge.Value) *merge.Object {
+// objectForPath expands a dotted key such as a.b.c into nested objects. The
+// objects created for the intermediate segments take the origin of the field.
+func objectForPath(parts []string, value merge.Value, origin *common.Origin) *merge.Object {
 	current := value
 	for i := len(parts) - 1; i >= 0; i-- {
 		obj := merge.NewObject(map[string]merge.Value{
 			parts[i]: current,
 		}, false)
+		obj.SetOrigin(origin)
 		current = obj
 	}
 	return current.(*merge.Object)
 }
 
-func valueFromRaw(path *common.Path, rv raw.Value) (merge.Value, error) {
+// valueFromRaw converts a raw value into its merge representation, carrying
+// over the origin recorded by the parser.
+func valueFromRaw(path, mount *common.Path, rv raw.Value, trace *merge.Trace) (merge.Value, error) {
+	val, err := convertRaw(path, mount, rv, trace)
+	if err != nil {
+		return nil, err
+	}
+	if val.Origin() == nil {
+		val.SetOrigin(rv.Origin())
+	}
+	return val, nil
+}
+
+func convertRaw(path, mount *common.Path, rv raw.Value, trace *merge.Trace) (merge.Value, error) {
 	switch v := rv.(type) {
 	case *raw.Object:
-		return buildMergeObject(path, v)
+		return buildMergeObject(path, mount, v, trace)
 	case *raw.Array:
 		values := make([]merge.Value, len(v.Values))
 		for i, item := range v.Values {
 			itemPath := appendIndex(path, uint(i))
-			val, err := valueFromRaw(itemPath, item)
+			val, err := valueFromRaw(itemPath, mount, item, trace)
 			if err != nil {
 				return nil, err
 			}
@@ -98,12 +119,14 @@ func valueFromRaw(path *common.Path, rv raw.Value) (merge.Value, error) {
 		if err != nil {
 			return nil, err
 		}
-		return merge.NewSubstitution(subPath, v.Optional), nil
+		substitution := merge.NewSubstitution(subPath, v.Optional)
+		substitution.Prefix = clonePath(mount)
+		return substitution, nil
 	case *raw.Concat:
 		values := make([]merge.Value, len(v.Values))
 		for i, val := range v.Values {
-			itemPath := appendIndex(path, uint(i))
-			merged, err := valueFromRaw(itemPath, val)
+			// The parts of a concatenation all make up the value at path.
+			merged, err := valueFromRaw(path, mount, val, trace)
 			if err != nil {
 				return nil, err
 			}
@@ -123,13 +146,13 @@ func valueFromRaw(path *common.Path, rv raw.Value) (merge.Value, error) {
 		}
 		return concat, nil
 	case *raw.AddAssign:
-		val, err := valueFromRaw(path, v.Val)
+		val, err := valueFromRaw(path, mount, v.Val, trace)
 		if err != nil {
 			return nil, err
 		}
 		return merge.NewAddAssign(val), nil
 	default:
-		return nil, fmt.Errorf("unsupported raw value %T", rv)
+		return nil, &common.BadValue{Path: displayPathOf(path), Reason: fmt.Sprintf("unsupported raw value %T", rv)}
 	}
 }
 
@@ -138,12 +161,7 @@ func objectToInterface(obj *merge.Object) (map[string]interface{}, error) {
 		return map[string]interface{}{}, nil
 	}
 	result := make(map[string]interface{}, len(obj.Values))
-	keys := make([]string, 0, len(obj.Values))
-	for k := range obj.Values {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, key := range keys {
+	for _, key := range obj.Keys() {
 		val, err := valueToInterface(obj.Values[key])
 		if err != nil {
 			return nil, err
@@ -210,7 +228,7 @@ func appendPathParts(parent *common.Path, parts []string) *common.Path {
 
 func stringsToPath(parts []string) (*common.Path, error) {
 	if len(parts) == 0 {
-		return nil, fmt.Errorf("path is empty")
+		return nil, &common.BadValue{Path: "${}", Reason: "substitution path is empty"}
 	}
 	var path *common.Path
 	for _, part := range parts {
@@ -264,3 +282,11 @@ func cloneKey(key common.Key) common.Key {
 		return key
 	}
 }
+
+// displayPathOf is displayPath for a parsed path; nil stands for the root.
+func displayPathOf(path *common.Path) string {
+	if path == nil {
+		return displayPath("")
+	}
+	return path.String()
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/raw"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tagName = "hocon"
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	byteSizeType        = reflect.TypeOf(ByteSize(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal decodes the whole resolved configuration into v, which must be a non-nil pointer.
+func Unmarshal(cfg *Config, v interface{}) error {
+	return cfg.Decode("", v)
+}
+
+// Decode decodes the value at path into v, which must be a non-nil pointer. An empty path
+// decodes the root object.
+//
+// Struct fields are matched against object keys using the `hocon` struct tag, falling back
+// to the field name and then to a case-insensitive match. The tag accepts the options
+// "required" (fail when the key is absent), "inline" or "squash" (decode the embedded or
+// nested struct from the enclosing object) and "omitempty", which is accepted for symmetry
+// with encoding/json and ignored when decoding. A tag of "-" skips the field.
+func (c *Config) Decode(path string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &common.BadValue{Path: displayPath(path), Reason: fmt.Sprintf("decode target must be a non-nil pointer, got %T", v)}
+	}
+	var val merge.Value
+	if path == "" {
+		root, err := c.resolved()
+		if err != nil {
+			return err
+		}
+		val = root
+	} else {
+		found, err := c.find(path)
+		if err != nil {
+			return err
+		}
+		val = found
+	}
+	return decodeValue(path, val, rv.Elem())
+}
+
+func decodeValue(path string, val merge.Value, out reflect.Value) error {
+	if _, ok := val.(*merge.Null); ok {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+	if out.Kind() == reflect.Pointer {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return decodeValue(path, val, out.Elem())
+	}
+	if out.CanAddr() && out.Addr().Type().Implements(textUnmarshalerType) {
+		s, err := stringValue(path, val)
+		if err != nil {
+			return wrongType(path, out.Type(), val)
+		}
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return &common.BadValue{Path: displayPath(path), Reason: fmt.Sprintf("cannot decode into %s: %v", out.Type(), err), Origin: val.Origin(), Err: err}
+		}
+		return nil
+	}
+	if out.Type() == durationType {
+		d, err := durationValue(path, val)
+		if err != nil {
+			return err
+		}
+		out.SetInt(int64(d))
+		return nil
+	}
+	if out.Type() == byteSizeType {
+		size, err := bytesValue(path, val)
+		if err != nil {
+			return err
+		}
+		out.SetInt(int64(size))
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		if out.NumMethod() != 0 {
+			return wrongType(path, out.Type(), val)
+		}
+		converted, err := valueToInterface(val)
+		if err != nil {
+			return err
+		}
+		if converted == nil {
+			out.Set(reflect.Zero(out.Type()))
+		} else {
+			out.Set(reflect.ValueOf(converted))
+		}
+		return nil
+	case reflect.String:
+		s, err := stringValue(path, val)
+		if err != nil {
+			return wrongType(path, out.Type(), val)
+		}
+		out.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := boolValue(path, val)
+		if err != nil {
+			return wrongType(path, out.Type(), val)
+		}
+		out.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := int64Value(path, val)
+		if err != nil || out.OverflowInt(n) {
+			return wrongType(path, out.Type(), val)
+		}
+		out.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := uint64Value(path, val)
+		if err != nil || out.OverflowUint(n) {
+			return wrongType(path, out.Type(), val)
+		}
+		out.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := float64Value(path, val)
+		if err != nil || out.OverflowFloat(f) {
+			return wrongType(path, out.Type(), val)
+		}
+		out.SetFloat(f)
+		return nil
+	case reflect.Slice:
+		arr, ok := val.(*merge.Array)
+		if !ok {
+			return wrongType(path, out.Type(), val)
+		}
+		slice := reflect.MakeSlice(out.Type(), len(arr.Values), len(arr.Values))
+		for i, item := range arr.Values {
+			if err := decodeValue(indexPath(path, i), item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		out.Set(slice)
+		return nil
+	case reflect.Array:
+		arr, ok := val.(*merge.Array)
+		if !ok || len(arr.Values) > out.Len() {
+			return wrongType(path, out.Type(), val)
+		}
+		for i, item := range arr.Values {
+			if err := decodeValue(indexPath(path, i), item, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		obj, ok := val.(*merge.Object)
+		if !ok || out.Type().Key().Kind() != reflect.String {
+			return wrongType(path, out.Type(), val)
+		}
+		if out.IsNil() {
+			out.Set(reflect.MakeMapWithSize(out.Type(), len(obj.Values)))
+		}
+		elemType := out.Type().Elem()
+		for key, child := range obj.Values {
+			if _, isNone := child.(*merge.None); isNone {
+				continue
+			}
+			elem := reflect.New(elemType).Elem()
+			if err := decodeValue(childPath(path, key), child, elem); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(out.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Struct:
+		obj, ok := val.(*merge.Object)
+		if !ok {
+			return wrongType(path, out.Type(), val)
+		}
+		return decodeStruct(path, obj, out)
+	default:
+		return &common.BadValue{Path: displayPath(path), Reason: fmt.Sprintf("cannot decode into unsupported type %s", out.Type()), Origin: val.Origin()}
+	}
+}
+
+func decodeStruct(path string, obj *merge.Object, out reflect.Value) error {
+	typ := out.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		opts := parseFieldTag(field)
+		if opts.skip {
+			continue
+		}
+		if !field.IsExported() && !(field.Anonymous && opts.inline && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		fieldVal := out.Field(i)
+		if opts.inline {
+			target := fieldVal
+			if target.Kind() == reflect.Pointer {
+				if target.IsNil() {
+					target.Set(reflect.New(target.Type().Elem()))
+				}
+				target = target.Elem()
+			}
+			if target.Kind() != reflect.Struct {
+				return &common.BadValue{Path: displayPath(path), Reason: fmt.Sprintf("field %s.%s is tagged inline but is not a struct", typ, field.Name)}
+			}
+			if err := decodeStruct(path, obj, target); err != nil {
+				return err
+			}
+			continue
+		}
+		key, child, found := lookupField(obj, opts.name)
+		if !found {
+			if opts.required {
+				return &common.PathNotFound{Path: childPath(path, opts.name), Origin: obj.Origin()}
+			}
+			continue
+		}
+		if err := decodeValue(childPath(path, key), child, fieldVal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fieldOptions struct {
+	name     string
+	skip     bool
+	required bool
+	inline   bool
+}
+
+func parseFieldTag(field reflect.StructField) fieldOptions {
+	tag, ok := field.Tag.Lookup(tagName)
+	if tag == "-" {
+		return fieldOptions{skip: true}
+	}
+	opts := fieldOptions{name: field.Name}
+	if !ok {
+		// Embedded structs without a tag behave like encoding/json: their fields are promoted.
+		opts.inline = field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct
+		return opts
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+	for _, option := range parts[1:] {
+		switch strings.TrimSpace(option) {
+		case "required":
+			opts.required = true
+		case "inline", "squash":
+			opts.inline = true
+		case "omitempty":
+			// Only meaningful when encoding; absent keys are always skipped when decoding.
+		}
+	}
+	return opts
+}
+
+// lookupField finds the key for a struct field, trying an exact match before a
+// case-insensitive one. Keys whose value is an unresolved optional substitution are ignored.
+func lookupField(obj *merge.Object, name string) (string, merge.Value, bool) {
+	if val, ok := obj.Values[name]; ok {
+		if _, isNone := val.(*merge.None); !isNone {
+			return name, val, true
+		}
+	}
+	for _, key := range obj.Keys() {
+		val := obj.Values[key]
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+		if _, isNone := val.(*merge.None); isNone {
+			continue
+		}
+		return key, val, true
+	}
+	return "", nil, false
+}
+
+func uint64Value(path string, val merge.Value) (uint64, error) {
+	if n, ok := val.(*merge.Number); ok {
+		if pos, ok := n.N.(*raw.PosInt); ok {
+			return pos.Val, nil
+		}
+	}
+	n, err := int64Value(path, val)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, &common.WrongType{Path: path, Expected: "unsigned number", Actual: "number " + strconv.FormatInt(n, 10), Origin: val.Origin()}
+	}
+	return uint64(n), nil
+}
+
+func wrongType(path string, typ reflect.Type, val merge.Value) error {
+	actual := val.Type()
+	switch v := val.(type) {
+	case *merge.String:
+		actual = "string " + strconv.Quote(v.Val)
+	case *merge.Number:
+		actual = "number " + v.String()
+	}
+	return &common.WrongType{Path: displayPath(path), Expected: typ.String(), Actual: actual, Origin: val.Origin()}
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func childPath(path string, key string) string {
+	if path == "" {
+		return quotePathSegment(key)
+	}
+	return path + "." + quotePathSegment(key)
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
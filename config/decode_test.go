@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type deserializeConf struct {
+	App struct {
+		Name     string `hocon:"name,required"`
+		Version  string `hocon:"version"`
+		Database struct {
+			Host     string                 `hocon:"host"`
+			Port     uint16                 `hocon:"port"`
+			User     string                 `hocon:"user"`
+			Password *string                `hocon:"password"`
+			Options  map[string]interface{} `hocon:"options"`
+		} `hocon:"database"`
+		Servers []struct {
+			Host  string   `hocon:"host"`
+			Roles []string `hocon:"roles"`
+		} `hocon:"servers"`
+		LogDir   string `hocon:"log_dir"`
+		Features struct {
+			Experimental   bool     `hocon:"experimental"`
+			MaxConnections int      `hocon:"max_connections"`
+			Tags           []string `hocon:"tags"`
+		} `hocon:"features"`
+	} `hocon:"app"`
+	Deployment struct {
+		deploymentCommon `hocon:",inline"`
+		Image            string `hocon:"image"`
+	} `hocon:"deployment"`
+}
+
+type deploymentCommon struct {
+	Replicas int `hocon:"replicas"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	cfg, err := ParseFile(filepath.Join(resourcesDir(t), "deserialize.conf"), nil)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	var out deserializeConf
+	if err := Unmarshal(cfg, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.App.Name != "MyApp" || out.App.Version != "1.2.3" {
+		t.Fatalf("unexpected app: %+v", out.App)
+	}
+	if out.App.Database.Port != 5432 || out.App.Database.Password == nil || *out.App.Database.Password != "secret" {
+		t.Fatalf("unexpected database: %+v", out.App.Database)
+	}
+	if !reflect.DeepEqual(out.App.Database.Options, map[string]interface{}{"ssl": true, "timeout": int64(30)}) {
+		t.Fatalf("unexpected options: %#v", out.App.Database.Options)
+	}
+	if len(out.App.Servers) != 2 || !reflect.DeepEqual(out.App.Servers[0].Roles, []string{"api", "worker"}) {
+		t.Fatalf("unexpected servers: %+v", out.App.Servers)
+	}
+	if out.App.LogDir != "/var/log MyApp" {
+		t.Fatalf("unexpected log dir: %q", out.App.LogDir)
+	}
+	if !out.App.Features.Experimental || out.App.Features.MaxConnections != 100 {
+		t.Fatalf("unexpected features: %+v", out.App.Features)
+	}
+	if out.Deployment.Replicas != 3 || out.Deployment.Image != "MyApp : 1.2.3" {
+		t.Fatalf("unexpected deployment: %+v", out.Deployment)
+	}
+}
+
+func TestDecodePath(t *testing.T) {
+	cfg, err := ParseString(`server { port = 8080, timeout = 250, hosts = [a, b] }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	var server struct {
+		Port    int
+		Timeout time.Duration
+		Hosts   [2]string
+	}
+	if err := cfg.Decode("server", &server); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if server.Port != 8080 || server.Timeout != 250*time.Millisecond || server.Hosts != [2]string{"a", "b"} {
+		t.Fatalf("unexpected server: %+v", server)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cfg, err := ParseString(`server { port = eighty, hosts = [a, {b = 1}] }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+
+	var port struct {
+		Port int `hocon:"port"`
+	}
+	err = cfg.Decode("server", &port)
+	var wrongType *common.WrongType
+	if !errors.As(err, &wrongType) || wrongType.Path != "server.port" || wrongType.Expected != "int" {
+		t.Fatalf("expected WrongType at server.port, got %v", err)
+	}
+
+	var hosts struct {
+		Hosts []string `hocon:"hosts"`
+	}
+	err = cfg.Decode("server", &hosts)
+	if !errors.As(err, &wrongType) || wrongType.Path != "server.hosts.1" {
+		t.Fatalf("expected WrongType at server.hosts.1, got %v", err)
+	}
+
+	var required struct {
+		Name string `hocon:"name,required"`
+	}
+	err = cfg.Decode("server", &required)
+	var notFound *common.PathNotFound
+	if !errors.As(err, &notFound) || notFound.Path != "server.name" {
+		t.Fatalf("expected PathNotFound at server.name, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"os"
+	"sort"
+	"strings"
+)
+
+// applyLayers puts the override layers selected by opts on top of obj: the
+// "path=value" overrides first, then the environment override layer.
+func applyLayers(obj *raw.Object, opts parser.ConfigOptions) (*raw.Object, error) {
+	obj, err := applyOverrides(obj, opts.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	return applyEnvOverrides(obj, opts.EnvOverridePrefix, opts.Environment)
+}
+
+// applyEnvOverrides returns obj with a field appended for every variable in
+// env whose name starts with prefix. Values are strings. Without a prefix the
+// layer is disabled; a nil env stands for the process environment. A variable
+// named just prefix sets nothing and is skipped; any other name that does not
+// spell a path is reported as a *common.BadValue naming the variable.
+func applyEnvOverrides(obj *raw.Object, prefix string, env map[string]string) (*raw.Object, error) {
+	if prefix == "" {
+		return obj, nil
+	}
+	if env == nil {
+		env = environ()
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if strings.HasPrefix(name, prefix) && name != prefix {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return obj, nil
+	}
+	sort.Strings(names)
+	fields := make([]raw.ObjectField, 0, len(obj.Fields)+len(names))
+	fields = append(fields, obj.Fields...)
+	for _, name := range names {
+		parts, err := envOverridePath(strings.TrimPrefix(name, prefix))
+		if err != nil {
+			return nil, &common.BadValue{Path: name, Reason: "the variable does not name a path: " + err.Error(), Err: err}
+		}
+		origin := &common.Origin{File: "env var " + name}
+		var value raw.Value = raw.NewQuotedString(env[name])
+		value.SetOrigin(origin)
+		fields = append(fields, nestedField(parts, value, origin))
+	}
+	result := raw.NewObject(fields)
+	result.SetOrigin(obj.Origin())
+	return result, nil
+}
+
+// envOverridePath decodes the path spelled by a variable name: a single "_"
+// separates path elements, "__" stands for "-" and "___" for "_".
+func envOverridePath(name string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	for i := 0; i < len(name); {
+		if name[i] != '_' {
+			current.WriteByte(name[i])
+			i++
+			continue
+		}
+		run := 0
+		for i < len(name) && name[i] == '_' {
+			run++
+			i++
+		}
+		switch run {
+		case 1:
+			parts = append(parts, current.String())
+			current.Reset()
+		case 2:
+			current.WriteByte('-')
+		case 3:
+			current.WriteByte('_')
+		default:
+			return nil, fmt.Errorf("%d consecutive underscores have no meaning", run)
+		}
+	}
+	parts = append(parts, current.String())
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty path element in %q", name)
+		}
+	}
+	return parts, nil
+}
+
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
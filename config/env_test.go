@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"reflect"
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	opts := &parser.ConfigOptions{
+		EnvOverridePrefix: "CONFIG_FORCE_",
+		Overrides:         []string{"app.name = from-set"},
+		Environment: map[string]string{
+			"CONFIG_FORCE_app_name":         "from-env",
+			"CONFIG_FORCE_app_max__size":    "10",
+			"CONFIG_FORCE_app_snake___case": "yes",
+			"CONFIG_FORCE_db_url":           "jdbc:x",
+			"OTHER_app_name":                "ignored",
+		},
+	}
+	cfg, err := ParseString("app { name = file, max-size = 1 }\ndb { url = none, dsn = ${db.url} }", opts)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	actual, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	expected := map[string]interface{}{
+		"app": map[string]interface{}{"name": "from-env", "max-size": "10", "snake_case": "yes"},
+		"db":  map[string]interface{}{"url": "jdbc:x", "dsn": "jdbc:x"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+	if origin, err := cfg.Origin("app.name"); err != nil || origin.File != "env var CONFIG_FORCE_app_name" {
+		t.Fatalf("Origin(app.name) = %v, %v", origin, err)
+	}
+
+	// Without a prefix the layer is off.
+	opts.EnvOverridePrefix = ""
+	cfg, err = ParseString("a = 1", opts)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if a, err := cfg.GetInt("a"); err != nil || a != 1 {
+		t.Fatalf("GetInt(a) = %d, %v", a, err)
+	}
+}
+
+func TestEnvOverrideErrors(t *testing.T) {
+	for _, name := range []string{"CONFIG_FORCE_a_", "CONFIG_FORCE__a", "CONFIG_FORCE_a____b"} {
+		_, err := ParseString("a = 1", &parser.ConfigOptions{
+			EnvOverridePrefix: "CONFIG_FORCE_",
+			Environment:       map[string]string{name: "x"},
+		})
+		var badValue *common.BadValue
+		if !errors.As(err, &badValue) || badValue.Path != name {
+			t.Errorf("%s: expected a BadValue naming the variable, got %v", name, err)
+		}
+	}
+
+	// The bare prefix names no path and is ignored.
+	cfg, err := ParseString("a = 1", &parser.ConfigOptions{
+		EnvOverridePrefix: "CONFIG_FORCE_",
+		Environment:       map[string]string{"CONFIG_FORCE_": "x"},
+	})
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if keys, err := cfg.Keys(); err != nil || !reflect.DeepEqual(keys, []string{"a"}) {
+		t.Fatalf("Keys() = %v, %v", keys, err)
+	}
+}
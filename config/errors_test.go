@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"hocon-go/render"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorCategories(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.conf":       "include required(\"missing.conf\")\n",
+		"nested.conf":    "include \"app.conf\"\n",
+		"broken.conf":    "a = [1, 2\n",
+		"broken.json":    `{"a": }`,
+		"props.conf":     "include \"bad.properties\"\n",
+		"bad.properties": `a = \u12`,
+		"json.conf":      "include \"broken.json\"\n",
+	})
+	getters := `
+name = demo
+nothing = null
+timeout = "10 parsecs"
+`
+	getter := func(f func(*Config) error) func() error {
+		return func() error {
+			cfg, err := ParseString(getters, nil)
+			if err != nil {
+				return err
+			}
+			return f(cfg)
+		}
+	}
+	parse := func(text string) func() error {
+		return func() error {
+			cfg, err := ParseString(text, nil)
+			if err != nil {
+				return err
+			}
+			_, err = cfg.Resolve()
+			return err
+		}
+	}
+	parseFile := func(name string) func() error {
+		return func() error {
+			_, err := ParseFile(filepath.Join(dir, name), nil)
+			return err
+		}
+	}
+
+	cases := []struct {
+		name     string
+		run      func() error
+		category common.Category
+		origin   bool
+	}{
+		{"syntax", parse("a = [1, 2"), common.CategoryParse, true},
+		{"syntax in include", parseFile("broken.conf"), common.CategoryParse, true},
+		{"properties in include", parseFile("props.conf"), common.CategoryParse, true},
+		{"json in include", parseFile("json.conf"), common.CategoryParse, true},
+		{"missing file", parseFile("absent.conf"), common.CategoryIO, false},
+		{"required include", parseFile("app.conf"), common.CategoryIncludeNotFound, true},
+		{"required include below optional", parseFile("nested.conf"), common.CategoryIncludeNotFound, true},
+		{"missing", getter(func(c *Config) error { _, err := c.GetString("absent"); return err }), common.CategoryMissing, true},
+		{"null", getter(func(c *Config) error { _, err := c.GetString("nothing"); return err }), common.CategoryNull, true},
+		{"wrong type", getter(func(c *Config) error { _, err := c.GetInt("name"); return err }), common.CategoryWrongType, true},
+		{"bad value", getter(func(c *Config) error { _, err := c.GetDuration("timeout"); return err }), common.CategoryBadValue, true},
+		{"unresolved substitution", parse("a = ${b}"), common.CategoryUnresolvedSubstitution, true},
+		{"cycle", parse("a = ${b}\nb = ${a}"), common.CategoryCycle, true},
+		{"concatenation", parse("a = [1] {x = 1}"), common.CategoryWrongType, true},
+		{"override", func() error { _, err := ParseOverrides([]string{"a"}); return err }, common.CategoryParse, false},
+		{"invalid path", getter(func(c *Config) error { _, err := c.GetString("a..b"); return err }), common.CategoryBadValue, false},
+		{"decode into non-pointer", getter(func(c *Config) error { var name string; return c.Decode("name", name) }), common.CategoryBadValue, false},
+		{"decode into unsupported type", getter(func(c *Config) error { var name chan int; return c.Decode("name", &name) }), common.CategoryBadValue, true},
+		{"empty object key", func() error {
+			key := &raw.KeyValueField{Key: raw.NewPathExpressionString(nil), Value: raw.NewBoolean(true)}
+			cfg, err := FromRaw(raw.NewObject([]raw.ObjectField{key}), nil)
+			if err != nil {
+				return err
+			}
+			_, err = cfg.Resolve()
+			return err
+		}, common.CategoryBadValue, false},
+		{"explain without trace", getter(func(c *Config) error { _, err := c.Explain("name"); return err }), common.CategoryBadValue, false},
+		{"render unresolved as JSON", func() error {
+			cfg, err := ParseString("a = ${b}\nb = 1", nil)
+			if err != nil {
+				return err
+			}
+			_, err = cfg.RenderUnresolved(render.ConciseOptions())
+			return err
+		}, common.CategoryBadValue, true},
+		{"env override path", func() error {
+			_, err := ParseString("a = 1", &parser.ConfigOptions{EnvOverridePrefix: "CONFIG_FORCE_", Environment: map[string]string{"CONFIG_FORCE_a_": "x"}})
+			return err
+		}, common.CategoryBadValue, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.run()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if category := common.CategoryOf(err); category != tc.category {
+				t.Fatalf("category %v, expected %v: %v", category, tc.category, err)
+			}
+			if tc.origin && errorOrigin(err) == nil {
+				t.Fatalf("no origin in %v", err)
+			}
+		})
+	}
+}
+
+// errorOrigin returns the Origin field of the typed errors that have one.
+func errorOrigin(err error) *common.Origin {
+	var (
+		syntaxErr  *parser.SyntaxError
+		ioErr      *common.IOError
+		notFound   *common.IncludeNotFound
+		missing    *common.PathNotFound
+		null       *common.Null
+		wrongType  *common.WrongType
+		badValue   *common.BadValue
+		unresolved *common.SubstitutionNotFound
+		cycle      *common.SubstitutionCycle
+		concat     *common.ConcatenateDifferentType
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Origin()
+	case errors.As(err, &ioErr):
+		return ioErr.Origin
+	case errors.As(err, &notFound):
+		return notFound.Origin
+	case errors.As(err, &missing):
+		return missing.Origin
+	case errors.As(err, &null):
+		return null.Origin
+	case errors.As(err, &wrongType):
+		return wrongType.Origin
+	case errors.As(err, &badValue):
+		return badValue.Origin
+	case errors.As(err, &unresolved):
+		return unresolved.Origin
+	case errors.As(err, &cycle):
+		return cycle.Origin
+	case errors.As(err, &concat):
+		return concat.Origin
+	}
+	return nil
+}
+
+func TestErrorCategoriesKeepCauses(t *testing.T) {
+	_, err := ParseFile(filepath.Join(t.TempDir(), "absent.conf"), nil)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+	problems := &common.ValidationFailed{Problems: []error{
+		&common.PathNotFound{Path: "a"},
+		&common.WrongType{Path: "b", Expected: "number", Actual: "string"},
+	}}
+	var wrongType *common.WrongType
+	if !errors.As(problems, &wrongType) || wrongType.Path != "b" {
+		t.Fatalf("expected the WrongType problem, got %v", problems)
+	}
+	if common.CategoryOf(problems) != common.CategoryValidation {
+		t.Fatalf("expected a validation error, got %v", common.CategoryOf(problems))
+	}
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"hocon-go/render"
+	"strings"
+)
+
+// Explanation describes how the value at a path came to be.
+type Explanation struct {
+	Path string
+	// Value is the resolved value, as returned by Get.
+	Value interface{}
+	// Origin is the last field that set the path, or the origin of the value
+	// when only the paths below it were set.
+	Origin *common.Origin
+	// Definitions lists, in the order they were merged, the fields that set
+	// the path, the paths below it, or a path above it to something other
+	// than an object. Later definitions override earlier ones.
+	Definitions []Definition
+	// Substitutions lists the substitutions the value was resolved from.
+	Substitutions []SubstitutionStep
+}
+
+// Definition is a field as it was written.
+type Definition struct {
+	// Path is the full path the field sets.
+	Path string
+	// Value is the HOCON text of the value.
+	Value string
+	// Append reports a += field, which appends Value to an array.
+	Append bool
+	Origin *common.Origin
+}
+
+// SubstitutionStep is a substitution and the value it was resolved to.
+type SubstitutionStep struct {
+	// Expression is the substitution as written, such as ${db.port}.
+	Expression string
+	Origin     *common.Origin
+	// Target is the path the value was taken from. It is empty when the value
+	// came from the environment or another substitution source, or when
+	// Missing is set.
+	Target string
+	// SelfReference reports that Target is the path being defined, and the
+	// value is the one it had before.
+	SelfReference bool
+	// Missing reports an optional substitution without a value.
+	Missing bool
+	Value   interface{}
+	// Steps are the substitutions the value of Target was resolved from.
+	Steps []SubstitutionStep
+}
+
+// Explain reports where the value at path came from: every field that set
+// it, including the ones that were overridden, and the chain of
+// substitutions it was resolved from. The history is recorded while the
+// configuration is resolved, which only happens when the Config was created
+// with ConfigOptions.Trace set.
+func (c *Config) Explain(path string) (*Explanation, error) {
+	val, err := c.find(path)
+	if err != nil {
+		return nil, err
+	}
+	if c.trace == nil {
+		return nil, &common.BadValue{Path: path, Reason: "cannot explain the value: the configuration was not resolved with ConfigOptions.Trace"}
+	}
+	parts, err := parser.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := valueToInterface(val)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &Explanation{
+		Path:          joinPath(parts),
+		Value:         value,
+		Origin:        val.Origin(),
+		Definitions:   []Definition{},
+		Substitutions: []SubstitutionStep{},
+	}
+	for _, def := range c.trace.Definitions {
+		if !definitionApplies(def, parts) {
+			continue
+		}
+		explanation.Definitions = append(explanation.Definitions, newDefinition(def))
+		// The resolved value carries the origin of whatever it was substituted
+		// from, so the origin reported is that of the last field setting the
+		// path itself.
+		if hasPathPrefix(parts, pathParts(def.Path)) {
+			explanation.Origin = def.Origin
+		}
+	}
+	explanation.Substitutions = c.substitutionSteps(parts, map[string]bool{joinPath(parts): true})
+	return explanation, nil
+}
+
+// definitionApplies reports whether def shaped the value at parts. Fields
+// holding objects are left out, as their own fields are recorded as well,
+// unless they set parts itself to an empty object.
+func definitionApplies(def merge.Definition, parts []string) bool {
+	defParts := pathParts(def.Path)
+	if obj, ok := def.Value.(*raw.Object); ok {
+		return len(obj.Fields) == 0 && hasPathPrefix(defParts, parts) && len(defParts) == len(parts)
+	}
+	return hasPathPrefix(defParts, parts) || hasPathPrefix(parts, defParts)
+}
+
+func newDefinition(def merge.Definition) Definition {
+	result := Definition{Path: joinPath(pathParts(def.Path)), Origin: def.Origin}
+	value := def.Value
+	if add, ok := value.(*raw.AddAssign); ok {
+		result.Append = true
+		value = add.Val
+	}
+	text, err := render.RawValue(value, render.Options{})
+	if err != nil {
+		text = value.String()
+	}
+	result.Value = text
+	return result
+}
+
+// substitutionSteps returns the substitutions resolved for the value at parts
+// and, under each, those of its target. Paths in visited are not followed
+// again.
+func (c *Config) substitutionSteps(parts []string, visited map[string]bool) []SubstitutionStep {
+	steps := []SubstitutionStep{}
+	for _, recorded := range c.trace.Substitutions {
+		if !hasPathPrefix(pathParts(recorded.Path), parts) {
+			continue
+		}
+		step := SubstitutionStep{
+			Expression:    recorded.Substitution.String(),
+			Origin:        recorded.Substitution.Origin(),
+			SelfReference: recorded.SelfReference,
+			Missing:       recorded.Value == nil,
+		}
+		if recorded.Value != nil {
+			if value, err := valueToInterface(recorded.Value); err == nil {
+				step.Value = value
+			}
+		}
+		if recorded.Target != nil {
+			targetParts := pathParts(recorded.Target)
+			step.Target = joinPath(targetParts)
+			if !recorded.SelfReference && !visited[step.Target] {
+				visited[step.Target] = true
+				step.Steps = c.substitutionSteps(targetParts, visited)
+				delete(visited, step.Target)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// pathParts returns the keys of path, with array indexes as numbers.
+func pathParts(path *common.Path) []string {
+	var parts []string
+	for p := path; p != nil; p = p.Remainder {
+		if key, ok := p.First.(*common.StrKey); ok {
+			parts = append(parts, key.Str)
+		} else {
+			parts = append(parts, p.First.String())
+		}
+	}
+	return parts
+}
+
+func hasPathPrefix(parts, prefix []string) bool {
+	if len(prefix) > len(parts) {
+		return false
+	}
+	for i := range prefix {
+		if parts[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the explanation on several lines, as printed by
+// `hocon explain`.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s = %s\n", e.Path, explainValue(e.Value))
+	fmt.Fprintf(&b, "  from %s\n", e.Origin)
+	if len(e.Definitions) > 0 {
+		b.WriteString("\ndefinitions, in the order they were merged:\n")
+		for _, def := range e.Definitions {
+			operator := "="
+			if def.Append {
+				operator = "+="
+			}
+			fmt.Fprintf(&b, "  %s: %s %s %s\n", def.Origin, def.Path, operator, def.Value)
+		}
+	}
+	if len(e.Substitutions) > 0 {
+		b.WriteString("\nsubstitutions:\n")
+		writeSteps(&b, e.Substitutions, 1)
+	}
+	return b.String()
+}
+
+func writeSteps(b *strings.Builder, steps []SubstitutionStep, depth int) {
+	for _, step := range steps {
+		fmt.Fprintf(b, "%s%s: %s", strings.Repeat("  ", depth), step.Origin, step.Expression)
+		switch {
+		case step.Missing:
+			b.WriteString(" is undefined\n")
+		case step.SelfReference:
+			fmt.Fprintf(b, " = %s, the earlier value of %s\n", explainValue(step.Value), step.Target)
+		case step.Target == "":
+			fmt.Fprintf(b, " = %s, from a substitution source\n", explainValue(step.Value))
+		default:
+			fmt.Fprintf(b, " = %s, from %s\n", explainValue(step.Value), step.Target)
+		}
+		writeSteps(b, step.Steps, depth+1)
+	}
+}
+
+func explainValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
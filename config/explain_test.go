@@ -0,0 +1,121 @@
+package config
+
+import (
+	"hocon-go/common"
+	"hocon-go/parser"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.conf": "root = /srv\napp {\n  db { port = 5432, host = localhost }\n  dir = ${root}\"/app\"\n}\n",
+		"main.conf": "include \"base.conf\"\napp.db.port = 6543\napp.logs = ${app.dir}\"/logs\"\napp.db = { port = ${?EXPLAIN_PORT} }\nlist = [1]\nlist += 2\n",
+	})
+	opts := parser.DefaultConfigOptions()
+	opts.Trace = true
+	opts.Overrides = []string{"app.db.port=7000"}
+	cfg, err := ParseFile(filepath.Join(dir, "main.conf"), &opts)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	port, err := cfg.Explain("app.db.port")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if port.Value != int64(7000) || port.Origin.File != "override" {
+		t.Fatalf("unexpected value %v from %v", port.Value, port.Origin)
+	}
+	var history []string
+	for _, def := range port.Definitions {
+		history = append(history, filepath.Base(def.Origin.File)+" "+def.Value)
+	}
+	expected := []string{"base.conf 5432", "main.conf 6543", "main.conf ${?EXPLAIN_PORT}", "override 7000"}
+	if !reflect.DeepEqual(history, expected) {
+		t.Fatalf("unexpected definitions %v", history)
+	}
+	if len(port.Substitutions) != 1 || !port.Substitutions[0].Missing || port.Substitutions[0].Origin.Line != 4 {
+		t.Fatalf("unexpected substitutions %+v", port.Substitutions)
+	}
+
+	logs, err := cfg.Explain("app.logs")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if len(logs.Substitutions) != 1 {
+		t.Fatalf("unexpected substitutions %+v", logs.Substitutions)
+	}
+	dirStep := logs.Substitutions[0]
+	if dirStep.Expression != "${app.dir}" || dirStep.Target != "app.dir" || dirStep.Value != "/srv/app" || len(dirStep.Steps) != 1 {
+		t.Fatalf("unexpected step %+v", dirStep)
+	}
+	if root := dirStep.Steps[0]; root.Target != "root" || root.Value != "/srv" || filepath.Base(root.Origin.File) != "base.conf" {
+		t.Fatalf("unexpected nested step %+v", root)
+	}
+
+	list, err := cfg.Explain("list")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if len(list.Definitions) != 2 || list.Definitions[0].Append || !list.Definitions[1].Append || list.Definitions[1].Value != "2" {
+		t.Fatalf("unexpected definitions %+v", list.Definitions)
+	}
+	text := list.String()
+	if !strings.HasPrefix(text, "list = [1,2]\n") || !strings.Contains(text, "main.conf:6:1: list += 2\n") {
+		t.Fatalf("unexpected text:\n%s", text)
+	}
+
+	if _, err := cfg.Explain("app.missing"); !isNotFound(err) {
+		t.Fatalf("expected a missing path, got %v", err)
+	}
+}
+
+func TestExplainSelfReference(t *testing.T) {
+	opts := &parser.ConfigOptions{Trace: true, SubstitutionSources: []common.SubstitutionSource{common.MapSource{"HOME": "/home/me"}}}
+	cfg, err := ParseString("path = /bin\npath = ${path}\":\"${HOME}\"/bin\"\n", opts)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	explanation, err := cfg.Explain("path")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if explanation.Value != "/bin:/home/me/bin" || len(explanation.Definitions) != 2 {
+		t.Fatalf("unexpected explanation %+v", explanation)
+	}
+	steps := explanation.Substitutions
+	if len(steps) != 2 || !steps[0].SelfReference || steps[0].Value != "/bin" || steps[1].Target != "" || steps[1].Value != "/home/me" {
+		t.Fatalf("unexpected substitutions %+v", steps)
+	}
+}
+
+func TestExplainOrigin(t *testing.T) {
+	cfg, err := ParseString("a.b = base\nx = ${a.b}\ny = 1\nx = ${x}\"s\"\n", &parser.ConfigOptions{Trace: true})
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	explanation, err := cfg.Explain("x")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if explanation.Value != "bases" || explanation.Origin.Line != 4 || explanation.Origin.Column != 1 {
+		t.Fatalf("unexpected value %v from %v", explanation.Value, explanation.Origin)
+	}
+	if !strings.HasPrefix(explanation.String(), "x = \"bases\"\n  from <input>:4:1\n") {
+		t.Fatalf("unexpected text:\n%s", explanation.String())
+	}
+}
+
+func TestExplainNeedsTrace(t *testing.T) {
+	cfg, err := ParseString("a = 1", nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if _, err := cfg.Explain("a"); err == nil || !strings.Contains(err.Error(), "ConfigOptions.Trace") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
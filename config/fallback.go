@@ -0,0 +1,88 @@
+package config
+
+import (
+	"hocon-go/merge"
+	"hocon-go/raw"
+)
+
+// WithFallback returns a new Config in which settings missing from c are taken
+// from fallback. Objects present in both are merged key by key, as if the
+// fallback document had been written before c. Neither config is modified.
+//
+// Substitutions are resolved only when the result is first used, across all
+// layers, so a ${...} in the fallback sees the values defined in c. Layering
+// resolved configs, such as those returned by GetConfig, works too; their
+// values are plain and contain no substitutions.
+func (c *Config) WithFallback(fallback *Config) *Config {
+	if fallback == nil {
+		return &Config{rawObj: c.document(), opts: c.opts}
+	}
+	top, bottom := c.document(), fallback.document()
+	fields := make([]raw.ObjectField, 0, len(bottom.Fields)+len(top.Fields))
+	fields = append(fields, bottom.Fields...)
+	fields = append(fields, top.Fields...)
+	combined := raw.NewObject(fields)
+	combined.SetOrigin(top.Origin())
+	opts := c.opts
+	if c.layer {
+		opts = fallback.opts
+	}
+	return &Config{rawObj: combined, opts: opts}
+}
+
+// document returns the unresolved document behind c. Configs created from a
+// resolved object are converted back into an equivalent document.
+func (c *Config) document() *raw.Object {
+	if c.rawObj != nil {
+		return c.rawObj
+	}
+	if c.root == nil {
+		return raw.NewObject(nil)
+	}
+	return rawFromMerge(c.root).(*raw.Object)
+}
+
+// rawFromMerge converts a resolved value back into its raw form. Values that
+// are not resolved cannot occur in a resolved tree and become null.
+func rawFromMerge(value merge.Value) raw.Value {
+	var result raw.Value
+	switch v := value.(type) {
+	case *merge.Object:
+		fields := make([]raw.ObjectField, 0, len(v.Values))
+		for _, key := range v.Keys() {
+			child := v.Values[key]
+			if _, isNone := child.(*merge.None); isNone {
+				continue
+			}
+			field := raw.NewKeyValueField(raw.NewQuotedString(key), rawFromMerge(child)).(*raw.KeyValueField)
+			field.SetOrigin(child.Origin())
+			fields = append(fields, field)
+		}
+		result = raw.NewObject(fields)
+	case *merge.Array:
+		values := make([]raw.Value, len(v.Values))
+		for i, item := range v.Values {
+			values[i] = rawFromMerge(item)
+		}
+		result = raw.NewRawArray(values)
+	case *merge.String:
+		result = raw.NewQuotedString(v.Val)
+	case *merge.Number:
+		switch n := v.N.(type) {
+		case *raw.PosInt:
+			result = raw.NewPosInt(n.Val)
+		case *raw.NegInt:
+			result = raw.NewNegInt(n.Val)
+		case *raw.Float:
+			result = raw.NewFloat(n.Val)
+		default:
+			result = &raw.Null{}
+		}
+	case *merge.Boolean:
+		result = raw.NewBoolean(v.Val)
+	default:
+		result = &raw.Null{}
+	}
+	result.SetOrigin(value.Origin())
+	return result
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithFallback(t *testing.T) {
+	reference, err := ParseString(`
+db { host = localhost, port = 5432, url = "jdbc://"${db.host}":"${db.port} }
+pool.size = 4
+tags = [a]
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	application, err := ParseString(`
+db.host = prod.example.com
+pool = 8
+tags += b
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	overrides, err := ParseString(`db.port = 6543`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+
+	cfg := overrides.WithFallback(application).WithFallback(reference)
+	actual, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "prod.example.com",
+			"port": int64(6543),
+			"url":  "jdbc://prod.example.com:6543",
+		},
+		"pool": int64(8),
+		"tags": []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+
+	// The layers themselves are left untouched.
+	if port, err := reference.GetInt("db.port"); err != nil || port != 5432 {
+		t.Fatalf("reference changed: %d, %v", port, err)
+	}
+	if _, err := application.Resolve(); err != nil {
+		t.Fatalf("application layer should still resolve on its own: %v", err)
+	}
+}
+
+func TestWithFallbackResolvedLayers(t *testing.T) {
+	base, err := ParseString(`outer { inner { a = 1, b = 2 } }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	inner, err := base.GetConfig("outer.inner")
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	top, err := ParseString(`b = 3, c = ${a}`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	actual, err := top.WithFallback(inner).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	expected := map[string]interface{}{"a": int64(1), "b": int64(3), "c": int64(1)}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+	keys, err := top.WithFallback(inner).Keys()
+	if err != nil || !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected keys %v, %v", keys, err)
+	}
+	if _, err := inner.WithFallback(nil).Resolve(); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	typeString  = "string"
+	typeNumber  = "number"
+	typeBoolean = "boolean"
+	typeObject  = "object"
+	typeArray   = "array"
+)
+
+// HasPath reports whether path exists and holds a non-null value.
+func (c *Config) HasPath(path string) (bool, error) {
+	val, err := c.find(path)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	_, isNull := val.(*merge.Null)
+	return !isNull, nil
+}
+
+// IsNull reports whether path is set to null. It fails if the path does not exist at all.
+func (c *Config) IsNull(path string) (bool, error) {
+	val, err := c.find(path)
+	if err != nil {
+		return false, err
+	}
+	_, isNull := val.(*merge.Null)
+	return isNull, nil
+}
+
+// Get returns the value at path converted to plain Go values: maps, slices,
+// strings, int64, uint64, float64, bool or nil.
+func (c *Config) Get(path string) (interface{}, error) {
+	val, err := c.find(path)
+	if err != nil {
+		return nil, err
+	}
+	return valueToInterface(val)
+}
+
+// Entry is a key of an object and its value, converted as by Get.
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// Keys returns the keys of the root object in the order they were defined.
+// Keys that are overridden keep the position of their first definition.
+func (c *Config) Keys() ([]string, error) {
+	root, err := c.resolved()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(root.Values))
+	for _, key := range root.Keys() {
+		if _, isNone := root.Values[key].(*merge.None); !isNone {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Entries returns the fields of the root object in the order reported by Keys.
+// Nested objects are plain maps; use GetConfig to walk them in order.
+func (c *Config) Entries() ([]Entry, error) {
+	keys, err := c.Keys()
+	if err != nil {
+		return nil, err
+	}
+	root, err := c.resolved()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		val, err := valueToInterface(root.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = Entry{Key: key, Value: val}
+	}
+	return entries, nil
+}
+
+// GetString returns the string at path. Numbers and booleans are converted to their text form.
+func (c *Config) GetString(path string) (string, error) {
+	val, err := c.get(path, typeString)
+	if err != nil {
+		return "", err
+	}
+	return stringValue(path, val)
+}
+
+// GetInt returns the integer at path.
+func (c *Config) GetInt(path string) (int, error) {
+	n, err := c.GetInt64(path)
+	if err != nil {
+		return 0, err
+	}
+	if n < math.MinInt || n > math.MaxInt {
+		return 0, &common.WrongType{Path: path, Expected: "int", Actual: "out of range number " + strconv.FormatInt(n, 10)}
+	}
+	return int(n), nil
+}
+
+// GetInt64 returns the 64-bit integer at path. Strings holding a number are converted.
+func (c *Config) GetInt64(path string) (int64, error) {
+	val, err := c.get(path, typeNumber)
+	if err != nil {
+		return 0, err
+	}
+	return int64Value(path, val)
+}
+
+// GetFloat64 returns the floating point number at path. Strings holding a number are converted.
+func (c *Config) GetFloat64(path string) (float64, error) {
+	val, err := c.get(path, typeNumber)
+	if err != nil {
+		return 0, err
+	}
+	return float64Value(path, val)
+}
+
+// GetBool returns the boolean at path. The strings true/yes/on and false/no/off are accepted.
+func (c *Config) GetBool(path string) (bool, error) {
+	val, err := c.get(path, typeBoolean)
+	if err != nil {
+		return false, err
+	}
+	return boolValue(path, val)
+}
+
+// GetDuration returns the duration at path, such as 10s, 1.5 hours or 1w. Bare numbers are
+// read as milliseconds.
+func (c *Config) GetDuration(path string) (time.Duration, error) {
+	val, err := c.get(path, "duration")
+	if err != nil {
+		return 0, err
+	}
+	return durationValue(path, val)
+}
+
+// GetStringList returns the array at path as a list of strings.
+func (c *Config) GetStringList(path string) ([]string, error) {
+	arr, err := c.getArray(path)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(arr.Values))
+	for i, item := range arr.Values {
+		s, err := stringValue(indexPath(path, i), item)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+// GetObject returns the object at path converted to plain Go values.
+func (c *Config) GetObject(path string) (map[string]interface{}, error) {
+	val, err := c.get(path, typeObject)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := val.(*merge.Object)
+	if !ok {
+		return nil, &common.WrongType{Path: path, Expected: typeObject, Actual: val.Type(), Origin: val.Origin()}
+	}
+	return objectToInterface(obj)
+}
+
+// GetConfig returns the object at path as a resolved Config.
+func (c *Config) GetConfig(path string) (*Config, error) {
+	val, err := c.get(path, typeObject)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := val.(*merge.Object)
+	if !ok {
+		return nil, &common.WrongType{Path: path, Expected: typeObject, Actual: val.Type(), Origin: val.Origin()}
+	}
+	return &Config{opts: c.opts, root: obj}, nil
+}
+
+// Origin reports where the value at path was defined. The origin is nil when it is unknown.
+func (c *Config) Origin(path string) (*common.Origin, error) {
+	val, err := c.find(path)
+	if err != nil {
+		return nil, err
+	}
+	return val.Origin(), nil
+}
+
+func (c *Config) getArray(path string) (*merge.Array, error) {
+	val, err := c.get(path, typeArray)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := val.(*merge.Array)
+	if !ok {
+		return nil, &common.WrongType{Path: path, Expected: typeArray, Actual: val.Type(), Origin: val.Origin()}
+	}
+	return arr, nil
+}
+
+// get looks up path and rejects null values, reporting expected as the wanted type.
+func (c *Config) get(path string, expected string) (merge.Value, error) {
+	val, err := c.find(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := val.(*merge.Null); ok {
+		return nil, &common.Null{Path: path, Expected: expected, Origin: val.Origin()}
+	}
+	return val, nil
+}
+
+// find walks the resolved tree along path. Null values are returned as is.
+func (c *Config) find(path string) (merge.Value, error) {
+	parts, err := parser.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := c.resolved()
+	if err != nil {
+		return nil, err
+	}
+	var current merge.Value = root
+	for i, part := range parts {
+		obj, ok := current.(*merge.Object)
+		if !ok {
+			return nil, &common.WrongType{Path: joinPath(parts[:i]), Expected: typeObject, Actual: current.Type(), Origin: current.Origin()}
+		}
+		child, ok := obj.Values[part]
+		if !ok {
+			return nil, &common.PathNotFound{Path: joinPath(parts), Origin: obj.Origin()}
+		}
+		if _, isNone := child.(*merge.None); isNone {
+			return nil, &common.PathNotFound{Path: joinPath(parts), Origin: obj.Origin()}
+		}
+		current = child
+	}
+	return current, nil
+}
+
+func isNotFound(err error) bool {
+	var notFound *common.PathNotFound
+	return errors.As(err, &notFound)
+}
+
+func stringValue(path string, val merge.Value) (string, error) {
+	switch v := val.(type) {
+	case *merge.String:
+		return v.Val, nil
+	case *merge.Number, *merge.Boolean:
+		return v.String(), nil
+	default:
+		return "", &common.WrongType{Path: path, Expected: typeString, Actual: val.Type(), Origin: val.Origin()}
+	}
+}
+
+func int64Value(path string, val merge.Value) (int64, error) {
+	switch v := val.(type) {
+	case *merge.Number:
+		switch n := v.N.(type) {
+		case *raw.PosInt:
+			if n.Val > math.MaxInt64 {
+				return 0, &common.WrongType{Path: path, Expected: "int64", Actual: "out of range number " + v.String(), Origin: val.Origin()}
+			}
+			return int64(n.Val), nil
+		case *raw.NegInt:
+			return n.Val, nil
+		case *raw.Float:
+			if n.Val != math.Trunc(n.Val) || n.Val < math.MinInt64 || n.Val >= math.MaxInt64 {
+				return 0, &common.WrongType{Path: path, Expected: "int64", Actual: "number " + v.String(), Origin: val.Origin()}
+			}
+			return int64(n.Val), nil
+		}
+	case *merge.String:
+		if number, err := raw.ParseNumber(v.Val); err == nil {
+			n := merge.NewNumber(number)
+			n.SetOrigin(v.Origin())
+			return int64Value(path, n)
+		}
+		return 0, &common.WrongType{Path: path, Expected: typeNumber, Actual: "string " + strconv.Quote(v.Val), Origin: val.Origin()}
+	}
+	return 0, &common.WrongType{Path: path, Expected: typeNumber, Actual: val.Type(), Origin: val.Origin()}
+}
+
+func float64Value(path string, val merge.Value) (float64, error) {
+	switch v := val.(type) {
+	case *merge.Number:
+		switch n := v.N.(type) {
+		case *raw.PosInt:
+			return float64(n.Val), nil
+		case *raw.NegInt:
+			return float64(n.Val), nil
+		case *raw.Float:
+			return n.Val, nil
+		}
+	case *merge.String:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v.Val), 64); err == nil {
+			return f, nil
+		}
+		return 0, &common.WrongType{Path: path, Expected: typeNumber, Actual: "string " + strconv.Quote(v.Val), Origin: val.Origin()}
+	}
+	return 0, &common.WrongType{Path: path, Expected: typeNumber, Actual: val.Type(), Origin: val.Origin()}
+}
+
+func boolValue(path string, val merge.Value) (bool, error) {
+	switch v := val.(type) {
+	case *merge.Boolean:
+		return v.Val, nil
+	case *merge.String:
+		switch strings.ToLower(strings.TrimSpace(v.Val)) {
+		case "true", "yes", "on":
+			return true, nil
+		case "false", "no", "off":
+			return false, nil
+		}
+		return false, &common.WrongType{Path: path, Expected: typeBoolean, Actual: "string " + strconv.Quote(v.Val), Origin: val.Origin()}
+	}
+	return false, &common.WrongType{Path: path, Expected: typeBoolean, Actual: val.Type(), Origin: val.Origin()}
+}
+
+// joinPath renders path segments back into a path expression, quoting segments when needed.
+func joinPath(parts []string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = quotePathSegment(part)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func quotePathSegment(segment string) string {
+	if segment == "" {
+		return `""`
+	}
+	for _, r := range segment {
+		if r < 0x80 && (parser.ForbiddenTable[r] || r == '.' || r <= ' ' || r == '/') {
+			return strconv.Quote(segment)
+		}
+	}
+	return segment
+}
+
+func indexPath(path string, idx int) string {
+	if path == "" {
+		return strconv.Itoa(idx)
+	}
+	return path + "." + strconv.Itoa(idx)
+}
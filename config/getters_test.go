@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const gettersConf = `
+app {
+  name = "demo"
+  database {
+    host = localhost
+    port = 5432
+    ratio = 0.75
+    enabled = yes
+    timeout = 1500
+  }
+  tags = [a, b, 3]
+  "dotted.key" = value
+  nothing = null
+}
+`
+
+func TestConfigGetters(t *testing.T) {
+	cfg, err := ParseString(gettersConf, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if s, err := cfg.GetString("app.database.host"); err != nil || s != "localhost" {
+		t.Fatalf("GetString: %q, %v", s, err)
+	}
+	if s, err := cfg.GetString("app.database.port"); err != nil || s != "5432" {
+		t.Fatalf("GetString on number: %q, %v", s, err)
+	}
+	if n, err := cfg.GetInt("app.database.port"); err != nil || n != 5432 {
+		t.Fatalf("GetInt: %d, %v", n, err)
+	}
+	if n, err := cfg.GetInt64("app.database.port"); err != nil || n != 5432 {
+		t.Fatalf("GetInt64: %d, %v", n, err)
+	}
+	if f, err := cfg.GetFloat64("app.database.ratio"); err != nil || f != 0.75 {
+		t.Fatalf("GetFloat64: %v, %v", f, err)
+	}
+	if b, err := cfg.GetBool("app.database.enabled"); err != nil || !b {
+		t.Fatalf("GetBool: %v, %v", b, err)
+	}
+	if d, err := cfg.GetDuration("app.database.timeout"); err != nil || d != 1500*time.Millisecond {
+		t.Fatalf("GetDuration: %v, %v", d, err)
+	}
+	if l, err := cfg.GetStringList("app.tags"); err != nil || !reflect.DeepEqual(l, []string{"a", "b", "3"}) {
+		t.Fatalf("GetStringList: %v, %v", l, err)
+	}
+	if s, err := cfg.GetString(`app."dotted.key"`); err != nil || s != "value" {
+		t.Fatalf("GetString quoted: %q, %v", s, err)
+	}
+	if obj, err := cfg.GetObject("app.database"); err != nil || obj["host"] != "localhost" {
+		t.Fatalf("GetObject: %v, %v", obj, err)
+	}
+	sub, err := cfg.GetConfig("app.database")
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	if n, err := sub.GetInt("port"); err != nil || n != 5432 {
+		t.Fatalf("sub.GetInt: %d, %v", n, err)
+	}
+	if ok, err := cfg.HasPath("app.nothing"); err != nil || ok {
+		t.Fatalf("HasPath(null): %v, %v", ok, err)
+	}
+	if ok, err := cfg.HasPath("app.name"); err != nil || !ok {
+		t.Fatalf("HasPath: %v, %v", ok, err)
+	}
+	if null, err := cfg.IsNull("app.nothing"); err != nil || !null {
+		t.Fatalf("IsNull: %v, %v", null, err)
+	}
+}
+
+func TestConfigGetterErrors(t *testing.T) {
+	cfg, err := ParseString(gettersConf, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+
+	_, err = cfg.GetString("app.database.missing")
+	var notFound *common.PathNotFound
+	if !errors.As(err, &notFound) || notFound.Path != "app.database.missing" {
+		t.Fatalf("expected PathNotFound for app.database.missing, got %v", err)
+	}
+
+	_, err = cfg.GetInt("app.name")
+	var wrongType *common.WrongType
+	if !errors.As(err, &wrongType) || wrongType.Path != "app.name" || wrongType.Expected != "number" {
+		t.Fatalf("expected WrongType for app.name, got %v", err)
+	}
+
+	_, err = cfg.GetString("app.name.first")
+	if !errors.As(err, &wrongType) || wrongType.Path != "app.name" || wrongType.Expected != "object" {
+		t.Fatalf("expected WrongType for app.name, got %v", err)
+	}
+
+	_, err = cfg.GetString("app.nothing")
+	var null *common.Null
+	if !errors.As(err, &null) || null.Path != "app.nothing" || null.Expected != "string" {
+		t.Fatalf("expected Null for app.nothing, got %v", err)
+	}
+
+	if _, err := cfg.IsNull("app.absent"); !errors.As(err, &notFound) {
+		t.Fatalf("expected PathNotFound from IsNull, got %v", err)
+	}
+}
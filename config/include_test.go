@@ -0,0 +1,52 @@
+package config
+
+import (
+	"hocon-go/parser"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIncludeRelativeSubstitutions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.conf": `
+host = root.example.com
+port = 1
+service { include "service.conf" }
+`,
+		"service.conf": `
+port = 8080
+url = "http://"${host}":"${port}
+db { include "db.conf" }
+`,
+		"db.conf": `
+name = orders
+# db.url is a self-reference here, so the service's url is named explicitly.
+url = ${service.url}"/"${name}
+`,
+	})
+	cfg, err := ParseFile(filepath.Join(dir, "main.conf"), &parser.ConfigOptions{})
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	actual, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	expected := map[string]interface{}{
+		"host": "root.example.com",
+		"port": int64(1),
+		"service": map[string]interface{}{
+			"port": int64(8080),
+			"url":  "http://root.example.com:8080",
+			"db": map[string]interface{}{
+				"name": "orders",
+				"url":  "http://root.example.com:8080/orders",
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+}
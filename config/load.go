@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Settings that replace application.conf as the entry file. They are read
+// from the overrides first and then from the environment variables below.
+const (
+	configFileSetting     = "config.file"
+	configResourceSetting = "config.resource"
+	configFileEnv         = "CONFIG_FILE"
+	configResourceEnv     = "CONFIG_RESOURCE"
+)
+
+// applicationSyntaxes lists the application files tried in each classpath
+// directory, highest precedence first.
+var applicationSyntaxes = []string{"application.conf", "application.json", "application.properties"}
+
+// LoadDefault loads the standard configuration stack, like ConfigFactory.load:
+//
+//   - every reference.conf on the classpath, earlier roots first, with the
+//     Classpath directories before the ClasspathFS file systems;
+//   - application.conf, application.json and application.properties on the
+//     classpath on top of them, or instead the file named by config.file or
+//     the classpath resource named by config.resource;
+//   - opts.Overrides and then the environment override layer, when
+//     opts.EnvOverridePrefix is set, on top of everything.
+//
+// config.file and config.resource are taken from opts.Overrides, as in
+// "config.file=prod.conf", or else from the CONFIG_FILE and CONFIG_RESOURCE
+// variables of opts.Environment, or of the process environment when it is
+// nil. Setting both is an error, and so is naming a file
+// that does not exist. The layers are resolved together, once, so
+// substitutions in reference.conf see the values set by the application.
+func LoadDefault(opts *parser.ConfigOptions) (*Config, error) {
+	options := normalizeOptions(opts)
+	fileOptions := options
+	fileOptions.Overrides = nil
+	fileOptions.EnvOverridePrefix = ""
+
+	reference, err := loadClasspath(fileOptions, []string{"reference.conf"})
+	if err != nil {
+		return nil, err
+	}
+	application, err := loadApplication(fileOptions, options.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := applyLayers(raw.NewObject(nil), options)
+	if err != nil {
+		return nil, err
+	}
+
+	top := &Config{rawObj: overrides, opts: options}
+	cfg := top.WithFallback(application).WithFallback(reference)
+	if _, err := cfg.resolved(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadApplication loads the entry file selected by the config.file and
+// config.resource settings, or the application files on the classpath.
+func loadApplication(opts parser.ConfigOptions, overrides []string) (*Config, error) {
+	file := loadSetting(overrides, opts.Environment, configFileSetting, configFileEnv)
+	resource := loadSetting(overrides, opts.Environment, configResourceSetting, configResourceEnv)
+	switch {
+	case file != "" && resource != "":
+		return nil, &common.BadValue{Path: configFileSetting, Reason: fmt.Sprintf("%s is set as well; use only one", configResourceSetting)}
+	case file != "":
+		return ParseFile(file, &opts)
+	case resource != "":
+		cfg, err := loadClasspath(opts, []string{strings.TrimPrefix(resource, "/")})
+		if err != nil {
+			return nil, err
+		}
+		if cfg.rawObj == nil {
+			return nil, &common.IOError{Resource: resource, Err: fmt.Errorf("%s %q not found on the classpath: %w", configResourceSetting, resource, os.ErrNotExist)}
+		}
+		return cfg, nil
+	default:
+		return loadClasspath(opts, applicationSyntaxes)
+	}
+}
+
+// loadClasspath merges every file with one of the given names found in the
+// classpath directories and file systems. Earlier roots, and earlier names
+// within a root, take precedence. The result has no document when nothing is
+// found.
+func loadClasspath(opts parser.ConfigOptions, names []string) (*Config, error) {
+	var result *Config
+	add := func(cfg *Config) {
+		if result == nil {
+			result = cfg
+		} else {
+			result = result.WithFallback(cfg)
+		}
+	}
+	for _, dir := range opts.Classpath {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			cfg, err := ParseFile(path, &opts)
+			if err != nil {
+				return nil, err
+			}
+			add(cfg)
+		}
+	}
+	for _, fsys := range opts.ClasspathFS {
+		for _, name := range names {
+			info, err := fs.Stat(fsys, name)
+			if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			obj, err := parser.ParseFS(fsys, name, opts)
+			if err != nil {
+				return nil, err
+			}
+			add(&Config{rawObj: obj, opts: opts})
+		}
+	}
+	if result == nil {
+		return &Config{opts: opts}, nil
+	}
+	return result, nil
+}
+
+// loadSetting returns the last value assigned to name in overrides, or the
+// value of the variable env in environment, or in the process environment
+// when environment is nil. Quoted values are unquoted.
+func loadSetting(overrides []string, environment map[string]string, name, env string) string {
+	for i := len(overrides) - 1; i >= 0; i-- {
+		key, value, ok := strings.Cut(overrides[i], "=")
+		if !ok || strings.TrimSpace(key) != name {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		return value
+	}
+	if environment != nil {
+		return environment[env]
+	}
+	return os.Getenv(env)
+}
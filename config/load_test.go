@@ -0,0 +1,141 @@
+package config
+
+import (
+	"hocon-go/parser"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+}
+
+func TestLoadDefault(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CONFIG_RESOURCE", "")
+	lib, app := t.TempDir(), t.TempDir()
+	writeFiles(t, lib, map[string]string{
+		"reference.conf": `
+lib { timeout = 10s, name = lib, greeting = "hello "${app.user} }
+app.user = nobody
+`,
+	})
+	writeFiles(t, app, map[string]string{
+		"reference.conf":         `app { user = guest, port = 80 }`,
+		"application.conf":       `app.port = 8080, lib.timeout = 5s`,
+		"application.json":       `{"app": {"port": 1, "mode": "json"}}`,
+		"application.properties": "app.mode = properties\napp.extra = yes",
+		"prod.conf":              `app.user = admin`,
+	})
+	opts := parser.DefaultConfigOptions()
+	opts.Classpath = []string{app, lib}
+
+	load := func(overrides ...string) map[string]interface{} {
+		t.Helper()
+		opts := opts
+		opts.Overrides = overrides
+		cfg, err := LoadDefault(&opts)
+		if err != nil {
+			t.Fatalf("LoadDefault: %v", err)
+		}
+		actual, err := cfg.Resolve()
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		return actual
+	}
+
+	expected := map[string]interface{}{
+		"lib": map[string]interface{}{"timeout": "5s", "name": "lib", "greeting": "hello guest"},
+		"app": map[string]interface{}{"user": "guest", "port": int64(8080), "mode": "json", "extra": "yes"},
+	}
+	if actual := load(); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected default stack %v", actual)
+	}
+
+	actual := load("config.resource=prod.conf", "app.port=9090")
+	expected = map[string]interface{}{
+		"lib":    map[string]interface{}{"timeout": "10s", "name": "lib", "greeting": "hello admin"},
+		"app":    map[string]interface{}{"user": "admin", "port": int64(9090)},
+		"config": map[string]interface{}{"resource": "prod.conf"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected resource stack %v", actual)
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(app, "prod.conf"))
+	if user := load()["app"].(map[string]interface{})["user"]; user != "admin" {
+		t.Fatalf("CONFIG_FILE ignored, user = %v", user)
+	}
+
+	// An explicit environment replaces the process environment.
+	opts.Environment = map[string]string{}
+	if user := load()["app"].(map[string]interface{})["user"]; user != "guest" {
+		t.Fatalf("process CONFIG_FILE used with opts.Environment, user = %v", user)
+	}
+	opts.Environment = map[string]string{"CONFIG_RESOURCE": "prod.conf"}
+	if user := load()["app"].(map[string]interface{})["user"]; user != "admin" {
+		t.Fatalf("CONFIG_RESOURCE in opts.Environment ignored, user = %v", user)
+	}
+}
+
+func TestLoadDefaultErrors(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CONFIG_RESOURCE", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"reference.conf": `a = ${missing}`})
+	opts := parser.DefaultConfigOptions()
+	opts.Classpath = []string{dir}
+
+	cases := map[string][]string{
+		"unresolved":       nil,
+		"missing file":     {"a=1", "config.file=" + filepath.Join(dir, "nope.conf")},
+		"missing resource": {"a=1", "config.resource=nope.conf"},
+		"both":             {"a=1", "config.file=x.conf", "config.resource=y.conf"},
+	}
+	for name, overrides := range cases {
+		opts := opts
+		opts.Overrides = overrides
+		if _, err := LoadDefault(&opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadDefaultClasspathFS(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CONFIG_RESOURCE", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"application.conf": `server.port = 9000`})
+	opts := parser.DefaultConfigOptions()
+	opts.Classpath = []string{dir}
+	opts.ClasspathFS = []fs.FS{fstest.MapFS{
+		"reference.conf":       {Data: []byte("server { include \"defaults/server\" }\nserver.url = \"http://\"${server.host}\":\"${server.port}")},
+		"defaults/server.conf": {Data: []byte(`host = localhost, port = 80`)},
+		"application.conf":     {Data: []byte(`server.host = embedded, server.port = 1`)},
+	}}
+	cfg, err := LoadDefault(&opts)
+	if err != nil {
+		t.Fatalf("LoadDefault: %v", err)
+	}
+	if url, err := cfg.GetString("server.url"); err != nil || url != "http://embedded:9000" {
+		t.Fatalf("GetString(server.url) = %q, %v", url, err)
+	}
+
+	cfg, err = ParseFS(opts.ClasspathFS[0], "defaults/server", nil)
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	if host, err := cfg.GetString("host"); err != nil || host != "localhost" {
+		t.Fatalf("GetString(host) = %q, %v", host, err)
+	}
+}
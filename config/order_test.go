@@ -0,0 +1,105 @@
+package config
+
+import (
+	"hocon-go/render"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeyOrder(t *testing.T) {
+	cfg, err := ParseString(`
+zeta = 1
+alpha = 2
+plugins {
+  metrics = on
+  auth = on
+  cache = on
+}
+mid = ${alpha}
+alpha = 3
+plugins.audit = on
+plugins { auth = off }
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	keys, err := cfg.Keys()
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"zeta", "alpha", "plugins", "mid"}) {
+		t.Fatalf("unexpected root keys %v", keys)
+	}
+	plugins, err := cfg.GetConfig("plugins")
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	keys, err = plugins.Keys()
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"metrics", "auth", "cache", "audit"}) {
+		t.Fatalf("unexpected plugin keys %v", keys)
+	}
+	entries, err := cfg.Entries()
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != 4 || entries[1] != (Entry{Key: "alpha", Value: int64(3)}) || entries[3] != (Entry{Key: "mid", Value: int64(3)}) {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	text, err := cfg.Render(render.ConciseOptions())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	expected := `{"zeta":1,"alpha":3,"plugins":{"metrics":"on","auth":"off","cache":"on","audit":"on"},"mid":3}`
+	if text != expected {
+		t.Fatalf("unexpected rendering %s", text)
+	}
+	sorted := render.ConciseOptions()
+	sorted.SortKeys = true
+	if text, _ = cfg.Render(sorted); text != `{"alpha":3,"mid":3,"plugins":{"audit":"on","auth":"off","cache":"on","metrics":"on"},"zeta":1}` {
+		t.Fatalf("unexpected sorted rendering %s", text)
+	}
+}
+
+func TestJSONKeyOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.json":  `{"zeta": 1, "alpha": {"mid": true, "beta": [1, 2], "aleph": null}, "gamma": "x", "zeta": 2}`,
+		"main.conf": "include \"app.json\"\ndelta = 4\n",
+	})
+	cfg, err := ParseFile(filepath.Join(dir, "main.conf"), nil)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	text, err := cfg.Render(render.ConciseOptions())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	expected := `{"zeta":2,"alpha":{"mid":true,"beta":[1,2],"aleph":null},"gamma":"x","delta":4}`
+	if text != expected {
+		t.Fatalf("unexpected rendering %s", text)
+	}
+}
+
+func TestSubstitutionErrorsAreDeterministic(t *testing.T) {
+	input := "a = ${missing1}\nb = ${missing2}\nc = ${missing3}\n"
+	var first string
+	for i := 0; i < 20; i++ {
+		cfg, err := ParseString(input, nil)
+		if err != nil {
+			t.Fatalf("ParseString: %v", err)
+		}
+		_, err = cfg.Resolve()
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		if i == 0 {
+			first = err.Error()
+		} else if err.Error() != first {
+			t.Fatalf("error changed between runs: %q vs %q", first, err.Error())
+		}
+	}
+}
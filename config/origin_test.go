@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "db.conf", "host = localhost\nport = 5432\n")
+	writeFile(t, dir, "app.json", `{"name": "demo"}`)
+	writeFile(t, dir, "main.conf", `app {
+  db { include "db.conf" }
+  include "app.json"
+  db.port = 5433
+  copy = ${app.db.host}
+  greeting = hello ${app.name}
+}
+`)
+	cfg, err := ParseFile(filepath.Join(dir, "main.conf"), nil)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	mainPath := filepath.Join(dir, "main.conf")
+	dbPath := filepath.Join(dir, "db.conf")
+	cases := []struct {
+		path     string
+		file     string
+		line     int
+		column   int
+		includes []string
+	}{
+		{"app", mainPath, 1, 5, nil},
+		{"app.db.host", dbPath, 1, 8, []string{mainPath}},
+		{"app.db.port", mainPath, 4, 13, nil},
+		{"app.copy", dbPath, 1, 8, []string{mainPath}},
+		{"app.greeting", mainPath, 6, 14, nil},
+		{"app.name", filepath.Join(dir, "app.json"), 0, 0, []string{mainPath}},
+	}
+	for _, tc := range cases {
+		origin, err := cfg.Origin(tc.path)
+		if err != nil {
+			t.Fatalf("Origin(%s): %v", tc.path, err)
+		}
+		if origin == nil {
+			t.Fatalf("Origin(%s) is nil", tc.path)
+		}
+		if origin.File != tc.file || origin.Line != tc.line || origin.Column != tc.column {
+			t.Fatalf("Origin(%s) = %s, expected %s:%d:%d", tc.path, origin, tc.file, tc.line, tc.column)
+		}
+		if len(origin.Includes) != len(tc.includes) || (len(tc.includes) > 0 && origin.Includes[0] != tc.includes[0]) {
+			t.Fatalf("Origin(%s) includes = %v, expected %v", tc.path, origin.Includes, tc.includes)
+		}
+	}
+}
+
+func TestOriginFromString(t *testing.T) {
+	cfg, err := ParseString("a = 1\nb {\n  c = [1, 2]\n}\n", nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	origin, err := cfg.Origin("b.c")
+	if err != nil {
+		t.Fatalf("Origin: %v", err)
+	}
+	if origin.File != "" || origin.Line != 3 || origin.Column != 7 || origin.Offset != 16 {
+		t.Fatalf("unexpected origin %+v", origin)
+	}
+	if origin.String() != "<input>:3:7" {
+		t.Fatalf("unexpected origin string %q", origin.String())
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", name, err)
+	}
+}
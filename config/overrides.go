@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"strings"
+)
+
+// overrideOrigin names overrides in value origins and errors.
+const overrideOrigin = "override"
+
+// OverrideError reports a malformed "path=value" override. Index is the
+// position of the override among the ones given, starting at 0.
+type OverrideError struct {
+	Index    int
+	Override string
+	Err      error
+}
+
+func (e *OverrideError) Error() string {
+	return fmt.Sprintf("invalid override %d (%q): %v", e.Index, e.Override, e.Err)
+}
+
+func (e *OverrideError) Unwrap() error {
+	return e.Err
+}
+
+func (*OverrideError) Category() common.Category {
+	return common.CategoryParse
+}
+
+// ParseOverrides parses command-line style "path=value" assignments, as given
+// to a --set flag, into a layer meant to be put on top of another Config:
+//
+//	layer, err := config.ParseOverrides([]string{"app.db.port=5433"})
+//	cfg = layer.WithFallback(cfg)
+//
+// Each value is parsed as a HOCON field value, so lists, objects and
+// substitutions work, and later assignments win over earlier ones. Values that
+// are not valid HOCON, such as a URL whose "//" would start a comment, are
+// taken as strings unless they start like a quoted string, array, object or
+// substitution. Errors are *OverrideError values naming the argument index.
+// The layer has no options of its own; WithFallback takes them from the
+// Config it is put on.
+func ParseOverrides(overrides []string) (*Config, error) {
+	obj, err := applyOverrides(raw.NewObject(nil), overrides)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{rawObj: obj, opts: parser.DefaultConfigOptions(), layer: true}, nil
+}
+
+// applyOverrides returns obj with the "path=value" overrides appended as
+// fields, so that they win over the document's own definitions.
+func applyOverrides(obj *raw.Object, overrides []string) (*raw.Object, error) {
+	if len(overrides) == 0 {
+		return obj, nil
+	}
+	fields := make([]raw.ObjectField, 0, len(obj.Fields)+len(overrides))
+	fields = append(fields, obj.Fields...)
+	for i, override := range overrides {
+		field, err := parseOverride(override)
+		if err != nil {
+			return nil, &OverrideError{Index: i, Override: override, Err: err}
+		}
+		fields = append(fields, field)
+	}
+	result := raw.NewObject(fields)
+	result.SetOrigin(obj.Origin())
+	return result, nil
+}
+
+func parseOverride(override string) (raw.ObjectField, error) {
+	path, text, ok := strings.Cut(override, "=")
+	if !ok {
+		return nil, errors.New("expected path=value")
+	}
+	parts, err := parser.ParsePath(strings.TrimSpace(path))
+	if err != nil {
+		return nil, err
+	}
+	value, err := parser.NewParser([]byte(text)).WithFilename(overrideOrigin).ParseValue()
+	if err != nil {
+		if isStructuredValue(text) {
+			return nil, err
+		}
+		value = raw.NewQuotedString(strings.TrimSpace(text))
+		value.SetOrigin(&common.Origin{File: overrideOrigin, Line: 1, Column: 1})
+	}
+	return nestedField(parts, value, value.Origin()), nil
+}
+
+// isStructuredValue reports whether text starts like a value that cannot be
+// meant as a plain string, so that failing to parse it is an error.
+func isStructuredValue(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") ||
+		strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "${")
+}
+
+// nestedField returns the field assigning value to the path made of parts,
+// with a nested object for every element but the last.
+func nestedField(parts []string, value raw.Value, origin *common.Origin) raw.ObjectField {
+	for i := len(parts) - 1; i > 0; i-- {
+		field := raw.NewKeyValueField(raw.NewQuotedString(parts[i]), value)
+		field.(*raw.KeyValueField).SetOrigin(origin)
+		value = raw.NewObject([]raw.ObjectField{field})
+		value.SetOrigin(origin)
+	}
+	field := raw.NewKeyValueField(raw.NewQuotedString(parts[0]), value)
+	field.(*raw.KeyValueField).SetOrigin(origin)
+	return field
+}
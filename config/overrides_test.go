@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/parser"
+	"reflect"
+	"testing"
+)
+
+func TestOverrides(t *testing.T) {
+	opts := &parser.ConfigOptions{Overrides: []string{
+		"a.b=2",
+		"list=[1, 2]",
+		"url=http://example.com/x",
+		`"quoted.key"=${a.b}`,
+		"obj={x = 1}",
+		"empty=",
+	}}
+	cfg, err := ParseString("a { b = 1, c = 3 }\nobj { y = 2 }\n", opts)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	actual, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	expected := map[string]interface{}{
+		"a":          map[string]interface{}{"b": int64(2), "c": int64(3)},
+		"list":       []interface{}{int64(1), int64(2)},
+		"url":        "http://example.com/x",
+		"quoted.key": int64(2),
+		"obj":        map[string]interface{}{"x": int64(1), "y": int64(2)},
+		"empty":      "",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+	origin, err := cfg.Origin("a.b")
+	if err != nil || origin.File != "override" {
+		t.Fatalf("unexpected origin %v, %v", origin, err)
+	}
+
+	for _, bad := range []string{"novalue", "a..b=1"} {
+		if _, err := ParseString("", &parser.ConfigOptions{Overrides: []string{bad}}); err == nil {
+			t.Fatalf("expected an error for override %q", bad)
+		}
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Setenv("HOCON_TEST_OVERRIDE_USER", "admin")
+	base, err := ParseString("app { db { host = localhost, port = 5432 }, name = demo }\n", &parser.ConfigOptions{UseSystemEnvironment: true})
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	layer, err := ParseOverrides([]string{
+		"app.db.port=5433",
+		"app.hosts=[a, b]",
+		"app.db.url=${app.db.host}/${app.db.port}",
+		"app.user=${HOCON_TEST_OVERRIDE_USER}",
+		"app.db.port=5434",
+	})
+	if err != nil {
+		t.Fatalf("ParseOverrides: %v", err)
+	}
+	cfg := layer.WithFallback(base)
+	if port, err := cfg.GetInt("app.db.port"); err != nil || port != 5434 {
+		t.Fatalf("app.db.port = %v, %v", port, err)
+	}
+	if hosts, err := cfg.GetStringList("app.hosts"); err != nil || !reflect.DeepEqual(hosts, []string{"a", "b"}) {
+		t.Fatalf("app.hosts = %v, %v", hosts, err)
+	}
+	if url, err := cfg.GetString("app.db.url"); err != nil || url != "localhost/5434" {
+		t.Fatalf("app.db.url = %q, %v", url, err)
+	}
+	// The layer uses the options of the config it is put on.
+	if user, err := cfg.GetString("app.user"); err != nil || user != "admin" {
+		t.Fatalf("app.user = %q, %v", user, err)
+	}
+	if name, err := cfg.GetString("app.name"); err != nil || name != "demo" {
+		t.Fatalf("app.name = %q, %v", name, err)
+	}
+
+	for _, tc := range []struct {
+		overrides []string
+		index     int
+	}{
+		{[]string{"a=1", "novalue"}, 1},
+		{[]string{"a..b=1"}, 0},
+		{[]string{"a=1", "b=2", "list=[1, 2"}, 2},
+		{[]string{"obj={x = 1} trailing}"}, 0},
+		{[]string{"list=[1, 2] # comment"}, 0},
+	} {
+		_, err := ParseOverrides(tc.overrides)
+		var overrideErr *OverrideError
+		if !errors.As(err, &overrideErr) || overrideErr.Index != tc.index {
+			t.Errorf("ParseOverrides(%q): expected an error for argument %d, got %v", tc.overrides, tc.index, err)
+		}
+	}
+}
+
+func TestOverrideCommentMarkers(t *testing.T) {
+	layer, err := ParseOverrides([]string{"p=/usr//lib", "pw=abc#123", "url=http://example.com/x", "n=5 // five"})
+	if err != nil {
+		t.Fatalf("ParseOverrides: %v", err)
+	}
+	for path, expected := range map[string]string{
+		"p":   "/usr//lib",
+		"pw":  "abc#123",
+		"url": "http://example.com/x",
+		"n":   "5 // five",
+	} {
+		if value, err := layer.GetString(path); err != nil || value != expected {
+			t.Errorf("%s = %q, %v; expected %q", path, value, err, expected)
+		}
+	}
+}
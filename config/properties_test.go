@@ -0,0 +1,47 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	const input = "db.host = localhost\ndb.port = 5432\ndb.url = jdbc:${db.host}\nflags.debug = true\n"
+	cfg, err := ParseProperties(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ParseProperties: %v", err)
+	}
+	actual, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	// Properties values are strings; substitutions are not expanded in them.
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": "5432",
+			"url":  "jdbc:${db.host}",
+		},
+		"flags": map[string]interface{}{"debug": "true"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %v", actual)
+	}
+	// Strings convert on access like any other value.
+	if port, err := cfg.GetInt("db.port"); err != nil || port != 5432 {
+		t.Fatalf("GetInt(db.port) = %d, %v", port, err)
+	}
+
+	rendered, err := cfg.RenderProperties()
+	if err != nil {
+		t.Fatalf("RenderProperties: %v", err)
+	}
+	again, err := ParseProperties(strings.NewReader(rendered), nil)
+	if err != nil {
+		t.Fatalf("ParseProperties(rendered): %v", err)
+	}
+	if roundTrip, err := again.Resolve(); err != nil || !reflect.DeepEqual(roundTrip, expected) {
+		t.Fatalf("round trip changed the document: %v, %v", roundTrip, err)
+	}
+}
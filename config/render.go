@@ -0,0 +1,42 @@
+package config
+
+import (
+	"hocon-go/render"
+)
+
+// Render writes the resolved configuration as HOCON or JSON text.
+func (c *Config) Render(opts render.Options) (string, error) {
+	root, err := c.resolved()
+	if err != nil {
+		return "", err
+	}
+	return render.Value(root, opts)
+}
+
+// RenderUnresolved writes the document as it was parsed, keeping substitutions,
+// include statements and, when enabled, comments. Configs without a parsed
+// document, such as those obtained with GetConfig, are rendered resolved.
+func (c *Config) RenderUnresolved(opts render.Options) (string, error) {
+	if c.rawObj == nil {
+		return c.Render(opts)
+	}
+	return render.Raw(c.rawObj, opts)
+}
+
+// RenderYAML writes the resolved configuration as YAML.
+func (c *Config) RenderYAML() (string, error) {
+	root, err := c.resolved()
+	if err != nil {
+		return "", err
+	}
+	return render.YAML(root)
+}
+
+// RenderProperties writes the resolved configuration as Java properties.
+func (c *Config) RenderProperties() (string, error) {
+	root, err := c.resolved()
+	if err != nil {
+		return "", err
+	}
+	return render.Properties(root)
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"hocon-go/render"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const renderInput = `# service settings
+app {
+  name = "demo service" // trailing
+  port = 8080
+  ratio = 2.0
+  tags = [a, "b c", 3]
+  "dotted.key" = true
+  nothing = null
+  empty {}
+}
+copy = ${app.name}
+greeting = hello ${app.name}
+list = [1] [2]
+`
+
+func TestRenderRoundTrip(t *testing.T) {
+	cfg, err := ParseString(renderInput, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	expected, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	concise := render.ConciseOptions()
+	sorted := render.DefaultOptions()
+	sorted.SortKeys = true
+	for name, opts := range map[string]render.Options{
+		"default":  render.DefaultOptions(),
+		"concise":  concise,
+		"hocon":    {},
+		"json":     {JSON: true, Formatted: true},
+		"sorted":   sorted,
+		"indented": {Formatted: true, Indent: "\t"},
+	} {
+		text, err := cfg.Render(opts)
+		if err != nil {
+			t.Fatalf("%s: Render: %v", name, err)
+		}
+		assertRendersTo(t, name, text, expected)
+	}
+	for name, opts := range map[string]render.Options{
+		"default": render.DefaultOptions(),
+		"hocon":   {},
+		"sorted":  sorted,
+	} {
+		text, err := cfg.RenderUnresolved(opts)
+		if err != nil {
+			t.Fatalf("%s: RenderUnresolved: %v", name, err)
+		}
+		assertRendersTo(t, "unresolved "+name, text, expected)
+	}
+}
+
+func TestRenderIncludeLikeKeys(t *testing.T) {
+	cfg, err := ParseString(`"includes" = [a, b], "included" = yes, "include-2" = 2, "include" { x = 1 }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	expected, err := cfg.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	for name, opts := range map[string]render.Options{"default": render.DefaultOptions(), "hocon": {}} {
+		text, err := cfg.Render(opts)
+		if err != nil {
+			t.Fatalf("%s: Render: %v", name, err)
+		}
+		assertRendersTo(t, name, text, expected)
+		if text, err = cfg.RenderUnresolved(opts); err != nil {
+			t.Fatalf("%s: RenderUnresolved: %v", name, err)
+		}
+		assertRendersTo(t, "unresolved "+name, text, expected)
+	}
+}
+
+func assertRendersTo(t *testing.T, name, text string, expected map[string]interface{}) {
+	t.Helper()
+	reparsed, err := ParseString(text, nil)
+	if err != nil {
+		t.Fatalf("%s: reparse %q: %v", name, text, err)
+	}
+	actual, err := reparsed.Resolve()
+	if err != nil {
+		t.Fatalf("%s: resolve %q: %v", name, text, err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("%s: round trip mismatch\nrendered:\n%s\nexpected %v\nactual   %v", name, text, expected, actual)
+	}
+}
+
+func TestRenderConcise(t *testing.T) {
+	cfg, err := ParseString(`b = 1, a { c = "x\ny", d = [1.5, false] }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	text, err := cfg.Render(render.ConciseOptions())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if text != `{"b":1,"a":{"c":"x\ny","d":[1.5,false]}}` {
+		t.Fatalf("unexpected output %s", text)
+	}
+}
+
+func TestRenderComments(t *testing.T) {
+	cfg, err := ParseString(renderInput, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	text, err := cfg.RenderUnresolved(render.Options{Formatted: true, Comments: true})
+	if err != nil {
+		t.Fatalf("RenderUnresolved: %v", err)
+	}
+	for _, want := range []string{"# service settings\n", `name = "demo service" // trailing`, "copy = ${app.name}", "greeting = hello ${app.name}"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in output:\n%s", want, text)
+		}
+	}
+	text, err = cfg.RenderUnresolved(render.Options{Formatted: true})
+	if err != nil {
+		t.Fatalf("RenderUnresolved: %v", err)
+	}
+	if strings.Contains(text, "#") || strings.Contains(text, "//") {
+		t.Fatalf("comments rendered although disabled:\n%s", text)
+	}
+	if _, err := cfg.RenderUnresolved(render.ConciseOptions()); err == nil {
+		t.Fatalf("expected an error rendering substitutions as JSON")
+	}
+}
+
+func TestRenderOriginComments(t *testing.T) {
+	cfg, err := ParseString("a = 1\nb {\n  c = 2\n}\n", nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	text, err := cfg.Render(render.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(text, "# <input>:1:5\na = 1") || !strings.Contains(text, "# <input>:3:7\n  c = 2") {
+		t.Fatalf("missing origin comments:\n%s", text)
+	}
+	text, err = cfg.Render(render.Options{JSON: true, Formatted: true, OriginComments: true})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(text, "#") {
+		t.Fatalf("origin comments rendered in JSON:\n%s", text)
+	}
+}
+
+func TestRenderUnresolvedWithoutDocument(t *testing.T) {
+	text, err := (&Config{}).RenderUnresolved(render.ConciseOptions())
+	if err != nil || text != "{}" {
+		t.Fatalf("RenderUnresolved() = %q, %v", text, err)
+	}
+}
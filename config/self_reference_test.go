@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelfReferentialSubstitutions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "more.conf"), []byte(`path = ${path}":/opt"`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cases := []struct {
+		name     string
+		conf     string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "string",
+			conf:     `path = "/bin", path = ${path}":/usr/bin", path = ${path}":/sbin"`,
+			expected: map[string]interface{}{"path": "/bin:/usr/bin:/sbin"},
+		},
+		{
+			name:     "array",
+			conf:     "a = [1]\na = ${a} [2]\na += 3",
+			expected: map[string]interface{}{"a": []interface{}{int64(1), int64(2), int64(3)}},
+		},
+		{
+			name:     "add assign",
+			conf:     "a = [x]\nb = ${a}\na += ${a}",
+			expected: map[string]interface{}{"a": []interface{}{"x", []interface{}{"x"}}, "b": []interface{}{"x", []interface{}{"x"}}},
+		},
+		{
+			name: "object",
+			conf: "a { x = 1 }\na = ${a} { y = ${a.x} }\nb = ${a}",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"x": int64(1), "y": int64(1)},
+				"b": map[string]interface{}{"x": int64(1), "y": int64(1)},
+			},
+		},
+		{
+			name:     "nested field",
+			conf:     "a.b = 1\na { b = ${a.b}0 }",
+			expected: map[string]interface{}{"a": map[string]interface{}{"b": "10"}},
+		},
+		{
+			name:     "optional without earlier value",
+			conf:     `a = ${?a} [1]`,
+			expected: map[string]interface{}{"a": []interface{}{int64(1)}},
+		},
+		{
+			name:     "included file",
+			conf:     "path = /bin\ninclude \"" + filepath.Join(dir, "more.conf") + "\"",
+			expected: map[string]interface{}{"path": "/bin:/opt"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseString(tc.conf, nil)
+			if err != nil {
+				t.Fatalf("ParseString: %v", err)
+			}
+			actual, err := cfg.Resolve()
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("unexpected result %v", actual)
+			}
+		})
+	}
+}
+
+func TestSelfReferenceFallsBackToSources(t *testing.T) {
+	opts := &parser.ConfigOptions{
+		SubstitutionSources: []common.SubstitutionSource{common.MapSource{"PATH": "/bin"}},
+	}
+	cfg, err := ParseString(`PATH = ${PATH}":/usr/bin"`, opts)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if path, err := cfg.GetString("PATH"); err != nil || path != "/bin:/usr/bin" {
+		t.Fatalf("GetString(PATH) = %q, %v", path, err)
+	}
+
+	cfg, err = ParseString(`a = ${a}`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	var notFound *common.SubstitutionNotFound
+	if _, err := cfg.Resolve(); !errors.As(err, &notFound) {
+		t.Fatalf("expected SubstitutionNotFound, got %v", err)
+	}
+}
+
+func TestSelfReferenceCycles(t *testing.T) {
+	// Only a field's own value and concatenations look back at the earlier
+	// value; a substitution nested in an object or array refers to the field
+	// being defined.
+	for _, conf := range []string{"a = 1, a = {b = ${a}}", "a = 1, a = [${a}]", "a = [1], a = [2, ${a}]"} {
+		cfg, err := ParseString(conf, nil)
+		if err != nil {
+			t.Fatalf("%s: ParseString: %v", conf, err)
+		}
+		var cycle *common.SubstitutionCycle
+		if _, err := cfg.Resolve(); !errors.As(err, &cycle) {
+			t.Errorf("%s: expected SubstitutionCycle, got %v", conf, err)
+		}
+	}
+}
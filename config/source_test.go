@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/parser"
+	"testing"
+)
+
+func TestSubstitutionSources(t *testing.T) {
+	const conf = `home = ${?HOCON_GO_TEST_HOME}, user = ${HOCON_GO_TEST_USER}`
+
+	t.Setenv("HOCON_GO_TEST_HOME", "/from/env")
+	t.Setenv("HOCON_GO_TEST_USER", "env-user")
+
+	t.Run("environment disabled", func(t *testing.T) {
+		cfg, err := ParseString(conf, nil)
+		if err != nil {
+			t.Fatalf("ParseString: %v", err)
+		}
+		_, err = cfg.Resolve()
+		var notFound *common.SubstitutionNotFound
+		if !errors.As(err, &notFound) || notFound.Path != "HOCON_GO_TEST_USER" {
+			t.Fatalf("expected SubstitutionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("map source", func(t *testing.T) {
+		cfg, err := ParseString(conf, &parser.ConfigOptions{
+			SubstitutionSources: []common.SubstitutionSource{
+				common.MapSource{"HOCON_GO_TEST_USER": "map-user"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ParseString: %v", err)
+		}
+		if ok, err := cfg.HasPath("home"); err != nil || ok {
+			t.Fatalf("expected home to stay unset, got %v, %v", ok, err)
+		}
+		if user, err := cfg.GetString("user"); err != nil || user != "map-user" {
+			t.Fatalf("GetString(user) = %q, %v", user, err)
+		}
+	})
+
+	t.Run("sources before environment", func(t *testing.T) {
+		cfg, err := ParseString(conf, &parser.ConfigOptions{
+			UseSystemEnvironment: true,
+			SubstitutionSources: []common.SubstitutionSource{
+				common.MapSource{"HOCON_GO_TEST_USER": "map-user"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ParseString: %v", err)
+		}
+		if home, err := cfg.GetString("home"); err != nil || home != "/from/env" {
+			t.Fatalf("GetString(home) = %q, %v", home, err)
+		}
+		if user, err := cfg.GetString("user"); err != nil || user != "map-user" {
+			t.Fatalf("GetString(user) = %q, %v", user, err)
+		}
+	})
+}
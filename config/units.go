@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ByteSize is a size in bytes read with the HOCON size units, such as 512M or 10 MiB.
+type ByteSize int64
+
+var durationUnits = map[string]time.Duration{}
+
+var byteSizeUnits = map[string]*big.Int{}
+
+func init() {
+	registerDuration(time.Nanosecond, "ns", "nano", "nanos", "nanosecond", "nanoseconds")
+	registerDuration(time.Microsecond, "us", "micro", "micros", "microsecond", "microseconds")
+	registerDuration(time.Millisecond, "", "ms", "milli", "millis", "millisecond", "milliseconds")
+	registerDuration(time.Second, "s", "second", "seconds")
+	registerDuration(time.Minute, "m", "minute", "minutes")
+	registerDuration(time.Hour, "h", "hour", "hours")
+	registerDuration(24*time.Hour, "d", "day", "days")
+	registerDuration(7*24*time.Hour, "w", "week", "weeks")
+
+	registerByteSize(big.NewInt(1), "", "B", "b", "byte", "bytes")
+	prefixes := []struct {
+		si, siName, iec, iecName string
+	}{
+		{"kB", "kilo", "K", "kibi"},
+		{"MB", "mega", "M", "mebi"},
+		{"GB", "giga", "G", "gibi"},
+		{"TB", "tera", "T", "tebi"},
+		{"PB", "peta", "P", "pebi"},
+		{"EB", "exa", "E", "exbi"},
+		{"ZB", "zetta", "Z", "zebi"},
+		{"YB", "yotta", "Y", "yobi"},
+	}
+	for i, p := range prefixes {
+		exp := big.NewInt(int64(i + 1))
+		decimal := new(big.Int).Exp(big.NewInt(1000), exp, nil)
+		binary := new(big.Int).Exp(big.NewInt(1024), exp, nil)
+		registerByteSize(decimal, p.si, p.siName+"byte", p.siName+"bytes")
+		registerByteSize(binary, p.iec, strings.ToLower(p.iec), p.iec+"i", p.iec+"iB", p.iecName+"byte", p.iecName+"bytes")
+	}
+}
+
+func registerDuration(unit time.Duration, names ...string) {
+	for _, name := range names {
+		durationUnits[name] = unit
+	}
+}
+
+func registerByteSize(unit *big.Int, names ...string) {
+	for _, name := range names {
+		byteSizeUnits[name] = unit
+	}
+}
+
+// GetBytes returns the size in bytes at path. Bare numbers are read as bytes.
+func (c *Config) GetBytes(path string) (ByteSize, error) {
+	val, err := c.get(path, "size in bytes")
+	if err != nil {
+		return 0, err
+	}
+	return bytesValue(path, val)
+}
+
+func durationValue(path string, val merge.Value) (time.Duration, error) {
+	text, err := unitText(path, val, "duration")
+	if err != nil {
+		return 0, err
+	}
+	number, unit, err := splitUnit(path, val, text)
+	if err != nil {
+		return 0, err
+	}
+	scale, ok := durationUnits[unit]
+	if !ok {
+		return 0, &common.BadValue{Path: path, Reason: fmt.Sprintf("unknown duration unit %q in %q", unit, text), Origin: val.Origin()}
+	}
+	n, err := scaleNumber(number, big.NewInt(int64(scale)))
+	if err != nil {
+		return 0, &common.BadValue{Path: path, Reason: fmt.Sprintf("duration %q %v", text, err), Origin: val.Origin()}
+	}
+	return time.Duration(n), nil
+}
+
+func bytesValue(path string, val merge.Value) (ByteSize, error) {
+	text, err := unitText(path, val, "size in bytes")
+	if err != nil {
+		return 0, err
+	}
+	number, unit, err := splitUnit(path, val, text)
+	if err != nil {
+		return 0, err
+	}
+	scale, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, &common.BadValue{Path: path, Reason: fmt.Sprintf("unknown size unit %q in %q", unit, text), Origin: val.Origin()}
+	}
+	n, err := scaleNumber(number, scale)
+	if err != nil {
+		return 0, &common.BadValue{Path: path, Reason: fmt.Sprintf("size %q %v", text, err), Origin: val.Origin()}
+	}
+	return ByteSize(n), nil
+}
+
+func unitText(path string, val merge.Value, expected string) (string, error) {
+	switch v := val.(type) {
+	case *merge.Number:
+		return v.String(), nil
+	case *merge.String:
+		return strings.TrimSpace(v.Val), nil
+	default:
+		return "", &common.WrongType{Path: path, Expected: expected, Actual: val.Type(), Origin: val.Origin()}
+	}
+}
+
+// splitUnit separates "10 MiB" into its number and unit parts. The unit is the
+// run of trailing letters and may be empty.
+func splitUnit(path string, val merge.Value, text string) (string, string, error) {
+	i := len(text)
+	for i > 0 && unicode.IsLetter(rune(text[i-1])) {
+		i--
+	}
+	number := strings.TrimSpace(text[:i])
+	unit := text[i:]
+	if number == "" {
+		return "", "", &common.BadValue{Path: path, Reason: fmt.Sprintf("no number in %q", text), Origin: val.Origin()}
+	}
+	return number, unit, nil
+}
+
+// scaleNumber multiplies the textual number by scale, failing when the result
+// does not fit into an int64.
+func scaleNumber(number string, scale *big.Int) (int64, error) {
+	if n, ok := new(big.Int).SetString(number, 10); ok {
+		n.Mul(n, scale)
+		if !n.IsInt64() {
+			return 0, fmt.Errorf("is out of range")
+		}
+		return n.Int64(), nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("has an invalid number %q", number)
+	}
+	product := new(big.Float).Mul(big.NewFloat(f), new(big.Float).SetInt(scale))
+	n, _ := product.Int(nil)
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("is out of range")
+	}
+	return n.Int64(), nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetDuration(t *testing.T) {
+	cfg, err := ParseString(`
+bare = 250
+float = 1.5 hours
+week = 1w
+words = 30 seconds
+nanos = 10ns
+micros = 7 us
+days = 2d
+quoted = "5 m"
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	cases := map[string]time.Duration{
+		"bare":   250 * time.Millisecond,
+		"float":  90 * time.Minute,
+		"week":   7 * 24 * time.Hour,
+		"words":  30 * time.Second,
+		"nanos":  10 * time.Nanosecond,
+		"micros": 7 * time.Microsecond,
+		"days":   48 * time.Hour,
+		"quoted": 5 * time.Minute,
+	}
+	for path, expected := range cases {
+		got, err := cfg.GetDuration(path)
+		if err != nil {
+			t.Fatalf("GetDuration(%s): %v", path, err)
+		}
+		if got != expected {
+			t.Fatalf("GetDuration(%s) = %v, expected %v", path, got, expected)
+		}
+	}
+
+	base, err := ParseFile(filepath.Join(resourcesDir(t), "base.conf"), nil)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if d, err := base.GetDuration("d"); err != nil || d != 7*24*time.Hour {
+		t.Fatalf("GetDuration(d) = %v, %v", d, err)
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	cfg, err := ParseString(`
+bare = 42
+mega = 512M
+mebi = 10MiB
+kilo = 3 kB
+kibi = 2k
+half = 0.5 KiB
+words = 1 gigabyte
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	cases := map[string]ByteSize{
+		"bare":  42,
+		"mega":  512 << 20,
+		"mebi":  10 << 20,
+		"kilo":  3000,
+		"kibi":  2048,
+		"half":  512,
+		"words": 1000 * 1000 * 1000,
+	}
+	for path, expected := range cases {
+		got, err := cfg.GetBytes(path)
+		if err != nil {
+			t.Fatalf("GetBytes(%s): %v", path, err)
+		}
+		if got != expected {
+			t.Fatalf("GetBytes(%s) = %d, expected %d", path, got, expected)
+		}
+	}
+}
+
+func TestUnitErrors(t *testing.T) {
+	cfg, err := ParseString(`timeout = 3 fortnights, size = 10 YiB, list = [1]`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	var bad *common.BadValue
+	if _, err := cfg.GetDuration("timeout"); !errors.As(err, &bad) || bad.Path != "timeout" || !strings.Contains(bad.Reason, "fortnights") {
+		t.Fatalf("expected BadValue naming the unit, got %v", err)
+	}
+	if _, err := cfg.GetBytes("size"); !errors.As(err, &bad) || bad.Path != "size" {
+		t.Fatalf("expected out of range BadValue, got %v", err)
+	}
+	var wrongType *common.WrongType
+	if _, err := cfg.GetDuration("list"); !errors.As(err, &wrongType) {
+		t.Fatalf("expected WrongType, got %v", err)
+	}
+}
+
+func TestDecodeUnits(t *testing.T) {
+	cfg, err := ParseString(`cache { ttl = 10 minutes, size = 64MiB }`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	var cache struct {
+		TTL  time.Duration `hocon:"ttl"`
+		Size ByteSize      `hocon:"size"`
+	}
+	if err := cfg.Decode("cache", &cache); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cache.TTL != 10*time.Minute || cache.Size != 64<<20 {
+		t.Fatalf("unexpected cache: %+v", cache)
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/parser"
+)
+
+// CheckValid checks c against reference, usually the defaults shipped with
+// an application, and reports every problem at once as a
+// *common.ValidationFailed. Its problems are a *common.PathNotFound for each
+// path of the reference that c lacks, a *common.WrongType for each value
+// whose type differs from the reference's, and a *common.BadValue for each
+// key of c that the reference does not define. Each carries the origin of
+// the offending value, or of the object lacking a path, when it is known.
+//
+// With restrictPaths, only the settings under those paths are checked. As
+// with Lightbend's checkValid, null is accepted anywhere, and so are strings
+// in place of numbers and booleans, since the getters convert them. The
+// elements of arrays are checked against the type of the first element of
+// the reference's array. Errors resolving either config are returned as is.
+func (c *Config) CheckValid(reference *Config, restrictPaths ...string) error {
+	ref, err := reference.resolved()
+	if err != nil {
+		return err
+	}
+	root, err := c.resolved()
+	if err != nil {
+		return err
+	}
+	var problems []error
+	if len(restrictPaths) == 0 {
+		problems = checkObject(nil, ref, root, problems)
+	}
+	for _, path := range restrictPaths {
+		parts, err := parser.ParsePath(path)
+		if err != nil {
+			return err
+		}
+		refVal, err := reference.find(path)
+		if err != nil {
+			// Nothing is expected where the reference has no settings.
+			continue
+		}
+		val, err := c.find(path)
+		if err != nil {
+			if category := common.CategoryOf(err); category != common.CategoryMissing && category != common.CategoryWrongType {
+				return err
+			}
+			problems = append(problems, err)
+			continue
+		}
+		problems = checkValue(parts, refVal, val, problems)
+	}
+	if len(problems) > 0 {
+		return &common.ValidationFailed{Problems: problems}
+	}
+	return nil
+}
+
+// checkObject appends the problems of obj, at parts, to problems: first the
+// keys of ref it lacks or holds values of the wrong type for, then the keys
+// ref does not have.
+func checkObject(parts []string, ref, obj *merge.Object, problems []error) []error {
+	for _, key := range ref.Keys() {
+		refVal := ref.Values[key]
+		if isNone(refVal) {
+			continue
+		}
+		path := append(parts[:len(parts):len(parts)], key)
+		val, ok := obj.Values[key]
+		if !ok || isNone(val) {
+			problems = append(problems, &common.PathNotFound{Path: joinPath(path), Origin: obj.Origin()})
+			continue
+		}
+		problems = checkValue(path, refVal, val, problems)
+	}
+	for _, key := range obj.Keys() {
+		val := obj.Values[key]
+		if refVal, ok := ref.Values[key]; (ok && !isNone(refVal)) || isNone(val) {
+			continue
+		}
+		path := append(parts[:len(parts):len(parts)], key)
+		problems = append(problems, &common.BadValue{
+			Path:   joinPath(path),
+			Reason: "the key is not defined in the reference configuration",
+			Origin: val.Origin(),
+		})
+	}
+	return problems
+}
+
+// checkValue appends the problems of val, at parts, to problems.
+func checkValue(parts []string, ref, val merge.Value, problems []error) []error {
+	path := joinPath(parts)
+	if !compatibleTypes(ref, val) {
+		return append(problems, &common.WrongType{Path: path, Expected: ref.Type(), Actual: val.Type(), Origin: val.Origin()})
+	}
+	switch r := ref.(type) {
+	case *merge.Object:
+		if obj, ok := val.(*merge.Object); ok {
+			return checkObject(parts, r, obj, problems)
+		}
+	case *merge.Array:
+		arr, ok := val.(*merge.Array)
+		if !ok || len(r.Values) == 0 {
+			return problems
+		}
+		element := r.Values[0]
+		for i, item := range arr.Values {
+			if !compatibleTypes(element, item) {
+				problems = append(problems, &common.WrongType{
+					Path:     indexPath(path, i),
+					Expected: element.Type(),
+					Actual:   item.Type(),
+					Origin:   item.Origin(),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// compatibleTypes reports whether val can stand where the reference has ref.
+func compatibleTypes(ref, val merge.Value) bool {
+	if isNull(ref) || isNull(val) {
+		return true
+	}
+	switch ref.(type) {
+	case *merge.Object:
+		_, ok := val.(*merge.Object)
+		return ok
+	case *merge.Array:
+		_, ok := val.(*merge.Array)
+		return ok
+	}
+	switch val.(type) {
+	case *merge.Object, *merge.Array:
+		return false
+	case *merge.String:
+		return true
+	}
+	if _, ok := ref.(*merge.String); ok {
+		return true
+	}
+	return ref.Type() == val.Type()
+}
+
+func isNull(val merge.Value) bool {
+	_, ok := val.(*merge.Null)
+	return ok
+}
+
+func isNone(val merge.Value) bool {
+	_, ok := val.(*merge.None)
+	return ok
+}
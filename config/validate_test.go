@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"hocon-go/common"
+	"reflect"
+	"testing"
+)
+
+func TestCheckValid(t *testing.T) {
+	reference, err := ParseString(`
+app {
+  name = demo
+  port = 8080
+  debug = false
+  hosts = [a]
+  db { url = "jdbc:x", pool = 4 }
+  extra = null
+}
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	cfg, err := ParseString(`
+app {
+  name = 42
+  port = "9090"
+  debug = {}
+  hosts = [b, [c]]
+  db { url = "jdbc:y", pol = 8 }
+  extra { anything = 1 }
+}
+other = 1
+`, nil)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+
+	err = cfg.CheckValid(reference)
+	var failed *common.ValidationFailed
+	if !errors.As(err, &failed) {
+		t.Fatalf("expected ValidationFailed, got %v", err)
+	}
+	var got []string
+	for _, problem := range failed.Problems {
+		var line int
+		switch p := problem.(type) {
+		case *common.PathNotFound:
+			got = append(got, "missing "+p.Path)
+			line = p.Origin.Line
+		case *common.WrongType:
+			got = append(got, "type "+p.Path+" "+p.Actual+" "+p.Expected)
+			line = p.Origin.Line
+		case *common.BadValue:
+			got = append(got, "unknown "+p.Path)
+			line = p.Origin.Line
+		default:
+			t.Fatalf("unexpected problem %T: %v", problem, problem)
+		}
+		if line == 0 {
+			t.Fatalf("problem without a line: %v", problem)
+		}
+	}
+	expected := []string{
+		"type app.debug object boolean",
+		"type app.hosts.1 array string",
+		"missing app.db.pool",
+		"unknown app.db.pol",
+		"unknown other",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected problems %q", got)
+	}
+	var wrongType *common.WrongType
+	if !errors.As(err, &wrongType) || wrongType.Origin.Line != 5 || common.CategoryOf(err) != common.CategoryValidation {
+		t.Fatalf("unexpected first wrong type %v", wrongType)
+	}
+
+	err = cfg.CheckValid(reference, "app.db", "app.name", "nothing")
+	if !errors.As(err, &failed) || len(failed.Problems) != 2 {
+		t.Fatalf("unexpected restricted result %v", err)
+	}
+
+	if err := cfg.WithFallback(reference).CheckValid(reference, "app.name", "app.port"); err != nil {
+		t.Fatalf("unexpected problems %v", err)
+	}
+	missing, _ := ParseString("other = 1", nil)
+	if err := missing.CheckValid(reference, "app.db.url"); !errors.As(err, &failed) || !isNotFound(failed.Problems[0]) {
+		t.Fatalf("expected a missing path, got %v", err)
+	}
+}
+
+func TestCheckValidResolutionError(t *testing.T) {
+	reference, _ := ParseString("a = 1", nil)
+	cfg, _ := ParseString("a = ${missing}", nil)
+	var notFound *common.SubstitutionNotFound
+	if err := cfg.CheckValid(reference); !errors.As(err, &notFound) {
+		t.Fatalf("expected the resolution error, got %v", err)
+	}
+}
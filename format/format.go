@@ -0,0 +1,340 @@
+// Package format reformats HOCON documents into a canonical layout while
+// keeping comments and the spelling of keys and values.
+package format
+
+import (
+	"hocon-go/parser"
+	"strings"
+)
+
+// CommaStyle controls the commas written between object fields.
+type CommaStyle int
+
+const (
+	// CommaNone separates fields by newlines only.
+	CommaNone CommaStyle = iota
+	// CommaSeparate writes a comma after every field but the last.
+	CommaSeparate
+	// CommaTrailing writes a comma after every field.
+	CommaTrailing
+)
+
+// BraceStyle controls the braces around the root object.
+type BraceStyle int
+
+const (
+	// BracesPreserve keeps the root braces when the input has them.
+	BracesPreserve BraceStyle = iota
+	// BracesOmit drops the root braces.
+	BracesOmit
+	// BracesKeep always writes the root braces.
+	BracesKeep
+)
+
+// Options configure the canonical layout.
+type Options struct {
+	// Indent is the indentation unit, two spaces by default.
+	Indent string
+	// Separator is written between keys and values, either "=" or ":".
+	Separator string
+	// SeparatorBeforeObject writes the separator before object values too,
+	// as in `a = { ... }` rather than `a { ... }`.
+	SeparatorBeforeObject bool
+	// Commas controls the commas between object fields. Elements of
+	// multi-line arrays are always separated by commas, and also end with
+	// one under CommaTrailing.
+	Commas CommaStyle
+	// RootBraces controls the braces around the root object.
+	RootBraces BraceStyle
+}
+
+// DefaultOptions returns the layout used by `hocon fmt` without flags.
+func DefaultOptions() Options {
+	return Options{Indent: "  ", Separator: "="}
+}
+
+// Source formats a HOCON document. Syntax errors are reported as *parser.SyntaxError.
+func Source(src []byte, opts Options) ([]byte, error) {
+	doc, err := parser.NewParser(src).ParseCST()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(Node(doc, opts)), nil
+}
+
+// Node formats a document produced by parser.ParseCST.
+func Node(doc *parser.CSTNode, opts Options) string {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	if opts.Separator == "" {
+		opts.Separator = "="
+	}
+	f := &formatter{opts: opts}
+	f.document(doc)
+	out := strings.TrimLeft(f.String(), "\n")
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+type formatter struct {
+	strings.Builder
+	opts Options
+}
+
+// entry is a field, include, value or standalone comment of an object or array.
+type entry struct {
+	node *parser.CSTNode
+	// comment is the trailing comment on the same line as node, or the text of
+	// a standalone comment when node is nil.
+	comment string
+	// blankBefore records a blank line before the entry in the input; runs of
+	// blank lines are collapsed into one.
+	blankBefore bool
+}
+
+// entries groups the children of an object, array or document, attaching
+// same-line comments to the entry they follow.
+func entries(children []*parser.CSTNode) []entry {
+	var result []entry
+	newlines := 0
+	for _, child := range children {
+		switch {
+		case child.Is(parser.TokenNewline):
+			newlines++
+		case child.Is(parser.TokenComment):
+			comment := strings.TrimRightFunc(child.Token.Text, isSpace)
+			last := len(result) - 1
+			if newlines == 0 && last >= 0 && result[last].node != nil && result[last].comment == "" {
+				result[last].comment = comment
+			} else {
+				result = append(result, entry{comment: comment, blankBefore: newlines > 1 && last >= 0})
+			}
+			newlines = 0
+		case child.Kind == parser.CSTToken:
+			// Braces, commas and whitespace are regenerated.
+		default:
+			result = append(result, entry{node: child, blankBefore: newlines > 1 && len(result) > 0})
+			newlines = 0
+		}
+	}
+	return result
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\ufeff'
+}
+
+func (f *formatter) newline(depth int) {
+	f.WriteByte('\n')
+	f.WriteString(strings.Repeat(f.opts.Indent, depth))
+}
+
+func (f *formatter) document(doc *parser.CSTNode) {
+	var root *parser.CSTNode
+	var before, after []*parser.CSTNode
+	for _, child := range doc.Children {
+		switch {
+		case child.Kind == parser.CSTObject:
+			root = child
+		case root == nil:
+			before = append(before, child)
+		default:
+			after = append(after, child)
+		}
+	}
+	f.comments(entries(before), 0)
+	if root != nil {
+		braced := root.Braced()
+		switch f.opts.RootBraces {
+		case BracesOmit:
+			braced = false
+		case BracesKeep:
+			braced = true
+		}
+		if braced {
+			f.newline(0)
+			f.object(root, 0)
+		} else {
+			f.body(entries(root.Children), 0)
+		}
+	}
+	f.comments(entries(after), 0)
+}
+
+// comments writes standalone comments found outside the root object.
+func (f *formatter) comments(list []entry, depth int) {
+	for _, e := range list {
+		if e.blankBefore {
+			f.WriteByte('\n')
+		}
+		f.newline(depth)
+		f.WriteString(e.comment)
+	}
+}
+
+// body writes the entries of an object, one per line, at depth.
+func (f *formatter) body(list []entry, depth int) {
+	lastField := -1
+	for i, e := range list {
+		if e.node != nil {
+			lastField = i
+		}
+	}
+	for i, e := range list {
+		if e.blankBefore {
+			f.WriteByte('\n')
+		}
+		f.newline(depth)
+		if e.node == nil {
+			f.WriteString(e.comment)
+			continue
+		}
+		if e.node.Kind == parser.CSTInclude {
+			f.include(e.node)
+		} else {
+			f.field(e.node, depth)
+		}
+		if f.opts.Commas == CommaTrailing || f.opts.Commas == CommaSeparate && i < lastField {
+			f.WriteByte(',')
+		}
+		if e.comment != "" {
+			f.WriteByte(' ')
+			f.WriteString(e.comment)
+		}
+	}
+}
+
+func (f *formatter) object(obj *parser.CSTNode, depth int) {
+	list := entries(obj.Children)
+	if len(list) == 0 {
+		f.WriteString("{}")
+		return
+	}
+	f.WriteByte('{')
+	f.body(list, depth+1)
+	f.newline(depth)
+	f.WriteByte('}')
+}
+
+func (f *formatter) include(node *parser.CSTNode) {
+	f.WriteString("include ")
+	var target strings.Builder
+	for _, child := range node.Children[1:] {
+		target.WriteString(child.Text())
+	}
+	f.WriteString(strings.TrimSpace(target.String()))
+}
+
+func (f *formatter) field(field *parser.CSTNode, depth int) {
+	var key, separator, value *parser.CSTNode
+	for _, child := range field.Children {
+		switch {
+		case child.Kind == parser.CSTKey:
+			key = child
+		case child.Kind == parser.CSTValue:
+			value = child
+		case child.Is(parser.TokenColon), child.Is(parser.TokenEquals), child.Is(parser.TokenPlusEquals):
+			separator = child
+		}
+	}
+	f.WriteString(key.Text())
+	switch {
+	case separator != nil && separator.Is(parser.TokenPlusEquals):
+		f.WriteString(" += ")
+	case !f.opts.SeparatorBeforeObject && len(value.Children) == 1 && value.Children[0].Kind == parser.CSTObject:
+		f.WriteByte(' ')
+	case f.opts.Separator == ":":
+		f.WriteString(": ")
+	default:
+		f.WriteString(" " + f.opts.Separator + " ")
+	}
+	f.value(value, depth)
+}
+
+// value writes the parts of a value. Whitespace between the parts of a
+// concatenation is part of the resulting string, so it is kept as is.
+func (f *formatter) value(value *parser.CSTNode, depth int) {
+	for _, part := range value.Children {
+		switch part.Kind {
+		case parser.CSTObject:
+			f.object(part, depth)
+		case parser.CSTArray:
+			f.array(part, depth)
+		default:
+			f.WriteString(part.Text())
+		}
+	}
+}
+
+func (f *formatter) array(arr *parser.CSTNode, depth int) {
+	list := entries(arr.Children)
+	if len(list) == 0 {
+		f.WriteString("[]")
+		return
+	}
+	if fitsOnOneLine(arr, list) {
+		f.WriteByte('[')
+		for i, e := range list {
+			if i > 0 {
+				f.WriteString(", ")
+			}
+			f.value(e.node, depth)
+		}
+		f.WriteByte(']')
+		return
+	}
+	lastValue := -1
+	for i, e := range list {
+		if e.node != nil {
+			lastValue = i
+		}
+	}
+	f.WriteByte('[')
+	for i, e := range list {
+		if e.blankBefore {
+			f.WriteByte('\n')
+		}
+		f.newline(depth + 1)
+		if e.node == nil {
+			f.WriteString(e.comment)
+			continue
+		}
+		f.value(e.node, depth+1)
+		if i < lastValue || f.opts.Commas == CommaTrailing {
+			f.WriteByte(',')
+		}
+		if e.comment != "" {
+			f.WriteByte(' ')
+			f.WriteString(e.comment)
+		}
+	}
+	f.newline(depth)
+	f.WriteByte(']')
+}
+
+// fitsOnOneLine reports whether an array is written on a single line: it was
+// on one line in the input, has no comments and holds no objects or arrays.
+func fitsOnOneLine(arr *parser.CSTNode, list []entry) bool {
+	for _, child := range arr.Children {
+		if child.Is(parser.TokenNewline) {
+			return false
+		}
+	}
+	for _, e := range list {
+		if e.node == nil || e.comment != "" {
+			return false
+		}
+		for _, part := range e.node.Children {
+			if part.Kind == parser.CSTObject || part.Kind == parser.CSTArray {
+				return false
+			}
+			if part.Is(parser.TokenMultilineString) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package format
+
+import (
+	"hocon-go/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFormatPreservesMeaning(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "resources", "*.conf"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	layouts := map[string]Options{
+		"default":  DefaultOptions(),
+		"json-ish": {Separator: ":", SeparatorBeforeObject: true, Commas: CommaSeparate, RootBraces: BracesKeep},
+		"trailing": {Indent: "\t", Commas: CommaTrailing, RootBraces: BracesOmit},
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		expected, expectedErr := resolve(string(data))
+		for layout, opts := range layouts {
+			name := filepath.Base(file) + "/" + layout
+			formatted, err := Source(data, opts)
+			if err != nil {
+				// Malformed fixtures must fail to parse either way.
+				if expectedErr == nil {
+					t.Fatalf("%s: Source: %v", name, err)
+				}
+				continue
+			}
+			again, err := Source(formatted, opts)
+			if err != nil {
+				t.Fatalf("%s: formatting output:\n%s\n%v", name, formatted, err)
+			}
+			if string(again) != string(formatted) {
+				t.Fatalf("%s: formatting is not idempotent\nfirst:\n%s\nsecond:\n%s", name, formatted, again)
+			}
+			if expectedErr != nil {
+				continue
+			}
+			actual, err := resolve(string(formatted))
+			if err != nil {
+				t.Fatalf("%s: resolving formatted output:\n%s\n%v", name, formatted, err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("%s: meaning changed\nformatted:\n%s\nexpected %v\nactual   %v", name, formatted, expected, actual)
+			}
+		}
+	}
+}
+
+func TestFormatSplitFields(t *testing.T) {
+	for _, input := range []string{"a =\n  1\n", "a:\n  \"x\"\n", "a\n{ b = 1 }\n", "a\n= 1\n", "a.b\n{ c = 1 }\n"} {
+		expected, err := resolve(input)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if _, ok := expected["a"]; !ok {
+			t.Fatalf("%q: key a missing from %v", input, expected)
+		}
+		formatted, err := Source([]byte(input), DefaultOptions())
+		if err != nil {
+			t.Fatalf("%q: Source: %v", input, err)
+		}
+		actual, err := resolve(string(formatted))
+		if err != nil {
+			t.Fatalf("%q: resolving formatted output:\n%s\n%v", input, formatted, err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("%q: meaning changed\nformatted:\n%s\nexpected %v\nactual   %v", input, formatted, expected, actual)
+		}
+	}
+}
+
+func resolve(text string) (map[string]interface{}, error) {
+	cfg, err := config.ParseString(text, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Resolve()
+}
+
+func TestFormatLayout(t *testing.T) {
+	input := `# header
+
+{
+a:1,b   :   "x" // trailing
+  nested={c=[1,2,3], d=[
+  {e=f}
+  ]}
+
+
+  list += ${a}   foo
+include "other.conf"
+}
+`
+	cases := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"default", DefaultOptions(), `# header
+{
+  a = 1
+  b = "x" // trailing
+  nested {
+    c = [1, 2, 3]
+    d = [
+      {
+        e = f
+      }
+    ]
+  }
+
+  list += ${a}   foo
+  include "other.conf"
+}
+`},
+		{"colon", Options{Separator: ":", SeparatorBeforeObject: true, Commas: CommaSeparate, RootBraces: BracesOmit, Indent: "    "}, `# header
+a: 1,
+b: "x", // trailing
+nested: {
+    c: [1, 2, 3],
+    d: [
+        {
+            e: f
+        }
+    ]
+},
+
+list += ${a}   foo,
+include "other.conf"
+`},
+	}
+	for _, tc := range cases {
+		out, err := Source([]byte(input), tc.opts)
+		if err != nil {
+			t.Fatalf("%s: Source: %v", tc.name, err)
+		}
+		if string(out) != tc.expected {
+			t.Fatalf("%s: unexpected output\n%s\nexpected\n%s", tc.name, out, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,674 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/config"
+	"hocon-go/parser"
+	"hocon-go/raw"
+	"hocon-go/render"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is an open text document and what the server knows about it.
+type document struct {
+	uri     string
+	version int
+	text    string
+	// file is the name values parsed from the document are attributed to: the
+	// absolute path for file URIs, the URI itself otherwise.
+	file string
+	// lines holds the offset of the start of every line.
+	lines []int
+
+	// cst is the concrete syntax tree, nil while the document has syntax
+	// errors. Navigation uses it for exact token positions.
+	cst *parser.CSTNode
+	// obj is the document as parsed in recovery mode, with its includes.
+	obj *raw.Object
+	// cfg is obj as a Config; nil when the override layers failed.
+	cfg         *config.Config
+	diagnostics []Diagnostic
+}
+
+func newDocument(uri string, version int, text string, opts parser.ConfigOptions) *document {
+	d := &document{uri: uri, version: version, text: text, file: uri}
+	if path := uriToPath(uri); path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		d.file = path
+	}
+	d.lines = []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.analyze(opts)
+	return d
+}
+
+// analyze parses the document and collects its diagnostics: every syntax
+// error and failed include, and, when there are none, the first resolution
+// error.
+func (d *document) analyze(opts parser.ConfigOptions) {
+	data := []byte(d.text)
+	var p *parser.Parser
+	if uriToPath(d.uri) != "" {
+		p = parser.NewFileParser(d.file, data, opts)
+	} else {
+		p = parser.NewParser(data).WithOptions(opts).WithFilename(d.file)
+	}
+	obj, parseDiags := p.ParseWithDiagnostics()
+	d.obj = obj
+	d.diagnostics = []Diagnostic{}
+	for _, diag := range parseDiags {
+		d.addDiagnostic(diag.Origin, diag.Include, diag.Message)
+	}
+	cst, err := parser.NewParser(data).WithFilename(d.file).ParseCST()
+	d.cst = cst
+	if err != nil && len(parseDiags) == 0 {
+		// Navigation, symbols and formatting all need the syntax tree, so a
+		// document it cannot represent must not go unnoticed.
+		d.addError(err)
+	}
+	cfg, err := config.FromRaw(obj, &opts)
+	if err != nil {
+		d.addError(err)
+		return
+	}
+	d.cfg = cfg
+	if len(parseDiags) == 0 {
+		if _, err := cfg.Resolve(); err != nil {
+			d.addError(err)
+		}
+	}
+}
+
+func (d *document) addError(err error) {
+	origin := errorOrigin(err)
+	message := err.Error()
+	if origin != nil {
+		message = strings.TrimPrefix(message, origin.String()+": ")
+	}
+	d.addDiagnostic(origin, nil, message)
+}
+
+// addDiagnostic reports message at origin. Problems found in included files
+// are reported on the include statement of the document that pulled the file
+// in, given by include when known, and name the place in the included file.
+func (d *document) addDiagnostic(origin, include *common.Origin, message string) {
+	rng := Range{}
+	switch {
+	case origin != nil && origin.File == d.file:
+		rng = d.wordRange(origin.Offset)
+	case origin != nil:
+		message = origin.String() + ": " + message
+		if include == nil || include.File != d.file {
+			include = d.includeOf(origin)
+		}
+		if include != nil {
+			rng = d.lineRange(include.Offset)
+		}
+	}
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Range:    rng,
+		Severity: SeverityError,
+		Source:   "hocon",
+		Message:  message,
+	})
+}
+
+// includeOf returns the origin of the include statement of the document
+// through which the file named by origin was loaded, or nil.
+func (d *document) includeOf(origin *common.Origin) *common.Origin {
+	// Includes lists the chain from the document inwards; the file the
+	// document included directly follows it.
+	target := origin.File
+	for i, file := range origin.Includes {
+		if file == d.file && i+1 < len(origin.Includes) {
+			target = origin.Includes[i+1]
+			break
+		}
+	}
+	var find func(obj *raw.Object) *common.Origin
+	find = func(obj *raw.Object) *common.Origin {
+		for _, field := range obj.Fields {
+			switch f := field.(type) {
+			case *raw.InclusionField:
+				val := f.Inclusion.Val
+				if f.Origin() != nil && f.Origin().File == d.file && val != nil && val.Origin() != nil && val.Origin().File == target {
+					return f.Origin()
+				}
+			case *raw.KeyValueField:
+				if inner, ok := f.Value.(*raw.Object); ok {
+					if found := find(inner); found != nil {
+						return found
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if d.obj == nil {
+		return nil
+	}
+	return find(d.obj)
+}
+
+// lineRange covers the statement starting at offset, up to the end of its
+// line or the comma after it.
+func (d *document) lineRange(offset int) Range {
+	end := offset
+	for end < len(d.text) && d.text[end] != '\n' && d.text[end] != ',' {
+		end++
+	}
+	for end > offset && (d.text[end-1] == ' ' || d.text[end-1] == '\t' || d.text[end-1] == '\r') {
+		end--
+	}
+	return d.rangeOf(offset, end)
+}
+
+// errorOrigin returns the position carried by err, if any.
+func errorOrigin(err error) *common.Origin {
+	var (
+		syntaxErr  *parser.SyntaxError
+		notFound   *common.IncludeNotFound
+		ioErr      *common.IOError
+		unresolved *common.SubstitutionNotFound
+		cycle      *common.SubstitutionCycle
+		concat     *common.ConcatenateDifferentType
+		wrongType  *common.WrongType
+		badValue   *common.BadValue
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Origin()
+	case errors.As(err, &notFound):
+		return notFound.Origin
+	case errors.As(err, &ioErr):
+		return ioErr.Origin
+	case errors.As(err, &unresolved):
+		return unresolved.Origin
+	case errors.As(err, &cycle):
+		return cycle.Origin
+	case errors.As(err, &concat):
+		return concat.Origin
+	case errors.As(err, &wrongType):
+		return wrongType.Origin
+	case errors.As(err, &badValue):
+		return badValue.Origin
+	}
+	return nil
+}
+
+// position converts a byte offset into a protocol position.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return Position{Line: line, Character: utf16Len(d.text[d.lines[line]:offset])}
+}
+
+// offset converts a protocol position into a byte offset, clamping it to the
+// line it names.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	start, end := d.lines[pos.Line], len(d.text)
+	if pos.Line+1 < len(d.lines) {
+		end = d.lines[pos.Line+1] - 1
+	}
+	units := 0
+	for i, r := range d.text[start:end] {
+		if units >= pos.Character {
+			return start + i
+		}
+		units += runeUnits(r)
+	}
+	return end
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// wordRange covers the substitution or word starting at offset, or the byte
+// there.
+func (d *document) wordRange(offset int) Range {
+	if offset < len(d.text) && strings.HasPrefix(d.text[offset:], "${") {
+		if end := strings.IndexAny(d.text[offset:], "}\n"); end > 0 && d.text[offset+end] == '}' {
+			return d.rangeOf(offset, offset+end+1)
+		}
+	}
+	end := offset
+	for end < len(d.text) && !strings.ContainsRune(" \t\r\n,:=[]{}", rune(d.text[end])) {
+		end++
+	}
+	if end == offset && end < len(d.text) && d.text[end] != '\n' {
+		end++
+	}
+	return d.rangeOf(offset, end)
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeUnits(r)
+	}
+	return n
+}
+
+func runeUnits(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// originLocation converts origin into a location. Positions in other files
+// are taken from the line and column of the origin.
+func (d *document) originLocation(origin *common.Origin) Location {
+	if origin.File == d.file {
+		pos := d.position(origin.Offset)
+		return Location{URI: d.uri, Range: Range{Start: pos, End: pos}}
+	}
+	pos := Position{}
+	if origin.Line > 0 {
+		pos = Position{Line: origin.Line - 1, Character: origin.Column - 1}
+	}
+	return Location{URI: fileURI(origin.File), Range: Range{Start: pos, End: pos}}
+}
+
+// target is what the cursor is on: a key or a substitution, both naming a
+// setting path, or an include statement.
+type target struct {
+	start, end int
+	// path is the setting named, nil when it cannot be addressed, as for the
+	// keys of objects within arrays.
+	path         []string
+	substitution bool
+	include      bool
+}
+
+// targetAt finds the key, substitution or include at offset.
+func (d *document) targetAt(offset int) *target {
+	if d.cst == nil {
+		return nil
+	}
+	for _, child := range d.cst.Children {
+		if child.Kind == parser.CSTObject {
+			return findInObject(child, []string{}, offset)
+		}
+	}
+	return nil
+}
+
+func findInObject(obj *parser.CSTNode, prefix []string, offset int) *target {
+	for _, child := range obj.Children {
+		start, end, ok := span(child)
+		if !ok || offset < start || offset > end {
+			continue
+		}
+		switch child.Kind {
+		case parser.CSTInclude:
+			return &target{start: start, end: end, include: true}
+		case parser.CSTField:
+			key := child.Children[0]
+			var path []string
+			if prefix != nil {
+				if segments, err := parser.ParsePath(strings.TrimSpace(key.Text())); err == nil {
+					path = append(append([]string{}, prefix...), segments...)
+				}
+			}
+			if keyStart, keyEnd, _ := span(key); offset >= keyStart && offset <= keyEnd {
+				return &target{start: keyStart, end: keyEnd, path: path}
+			}
+			return findInValue(child.Children[len(child.Children)-1], path, offset)
+		}
+	}
+	return nil
+}
+
+func findInValue(value *parser.CSTNode, path []string, offset int) *target {
+	for _, part := range value.Children {
+		start, end, ok := span(part)
+		if !ok || offset < start || offset > end {
+			continue
+		}
+		switch {
+		case part.Kind == parser.CSTObject:
+			return findInObject(part, path, offset)
+		case part.Kind == parser.CSTArray:
+			for _, elem := range part.Children {
+				if elem.Kind == parser.CSTValue {
+					if t := findInValue(elem, nil, offset); t != nil {
+						return t
+					}
+				}
+			}
+		case part.Is(parser.TokenSubstitution):
+			if segments, ok := substitutionPath(part.Token.Text); ok {
+				return &target{start: start, end: end, path: segments, substitution: true}
+			}
+		}
+	}
+	return nil
+}
+
+// substitutionPath returns the path of a substitution token such as ${?a.b}.
+func substitutionPath(text string) ([]string, bool) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(text, "${"), "}")
+	inner = strings.TrimSpace(strings.TrimPrefix(inner, "?"))
+	segments, err := parser.ParsePath(inner)
+	return segments, err == nil
+}
+
+// span returns the offsets of the source text covered by n.
+func span(n *parser.CSTNode) (int, int, bool) {
+	first, last := firstLeaf(n), lastLeaf(n)
+	if first == nil || last == nil {
+		return 0, 0, false
+	}
+	return first.Token.Offset, last.Token.Offset + len(last.Token.Text), true
+}
+
+func firstLeaf(n *parser.CSTNode) *parser.CSTNode {
+	if n.Token != nil {
+		return n
+	}
+	for _, child := range n.Children {
+		if leaf := firstLeaf(child); leaf != nil {
+			return leaf
+		}
+	}
+	return nil
+}
+
+func lastLeaf(n *parser.CSTNode) *parser.CSTNode {
+	if n.Token != nil {
+		return n
+	}
+	for i := len(n.Children) - 1; i >= 0; i-- {
+		if leaf := lastLeaf(n.Children[i]); leaf != nil {
+			return leaf
+		}
+	}
+	return nil
+}
+
+// layer is a field of the document or of one of its includes that sets a
+// path.
+type layer struct {
+	path []string
+	// depth is the length of the path of the object the field is in.
+	depth int
+	field *raw.KeyValueField
+}
+
+// contributes reports whether the field sets path itself, possibly through a
+// dotted key, or a value below it.
+func (l layer) contributes(path []string) bool {
+	return len(path) > l.depth && len(path) <= len(l.path) && hasPrefix(l.path, path)
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// layers returns the fields of the document and its includes in the order
+// they are merged.
+func (d *document) layers() []layer {
+	var result []layer
+	var collect func(obj *raw.Object, prefix []string)
+	collect = func(obj *raw.Object, prefix []string) {
+		for _, field := range obj.Fields {
+			switch f := field.(type) {
+			case *raw.KeyValueField:
+				path := append(append([]string{}, prefix...), f.Key.AsPath()...)
+				result = append(result, layer{path: path, depth: len(prefix), field: f})
+				if inner, ok := f.Value.(*raw.Object); ok {
+					collect(inner, path)
+				}
+			case *raw.InclusionField:
+				if f.Inclusion.Val != nil {
+					collect(f.Inclusion.Val, prefix)
+				}
+			}
+		}
+	}
+	if d.obj != nil {
+		collect(d.obj, nil)
+	}
+	return result
+}
+
+// definition returns where the substitution or include at pos points to.
+func (d *document) definition(pos Position) *Location {
+	t := d.targetAt(d.offset(pos))
+	switch {
+	case t == nil:
+		return nil
+	case t.include:
+		inclusion := d.inclusionAt(t.start)
+		if inclusion == nil || inclusion.Val == nil || inclusion.Val.Origin() == nil {
+			return nil
+		}
+		return &Location{URI: fileURI(inclusion.Val.Origin().File)}
+	case t.substitution:
+		var found *layer
+		for _, l := range d.layers() {
+			l := l
+			if equalPaths(l.path, t.path) {
+				found = &l
+			} else if found == nil && l.contributes(t.path) {
+				found = &l
+			}
+		}
+		if found == nil || found.field.Origin() == nil {
+			return nil
+		}
+		location := d.originLocation(found.field.Origin())
+		return &location
+	}
+	return nil
+}
+
+func equalPaths(a, b []string) bool {
+	return len(a) == len(b) && hasPrefix(a, b)
+}
+
+// inclusionAt returns the include statement of the document that starts at
+// offset.
+func (d *document) inclusionAt(offset int) *raw.Inclusion {
+	var find func(obj *raw.Object) *raw.Inclusion
+	find = func(obj *raw.Object) *raw.Inclusion {
+		for _, field := range obj.Fields {
+			switch f := field.(type) {
+			case *raw.InclusionField:
+				if origin := f.Origin(); origin != nil && origin.File == d.file && origin.Offset == offset {
+					return &f.Inclusion
+				}
+			case *raw.KeyValueField:
+				if inner, ok := f.Value.(*raw.Object); ok {
+					if found := find(inner); found != nil {
+						return found
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if d.obj == nil {
+		return nil
+	}
+	return find(d.obj)
+}
+
+// hover describes the setting at pos: its resolved value and the fields that
+// contributed to it, in the order they are merged.
+func (d *document) hover(pos Position) *Hover {
+	t := d.targetAt(d.offset(pos))
+	if t == nil || t.path == nil {
+		return nil
+	}
+	path := joinPath(t.path)
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**\n", path)
+	if d.cfg != nil {
+		value, err := d.cfg.Get(path)
+		if err == nil {
+			text, _ := json.MarshalIndent(value, "", "  ")
+			fmt.Fprintf(&b, "\n```json\n%s\n```\n", text)
+		} else {
+			fmt.Fprintf(&b, "\nNot resolved: %v\n", err)
+		}
+	}
+	var contributions []string
+	for _, l := range d.layers() {
+		if !l.contributes(t.path) {
+			continue
+		}
+		contributions = append(contributions, fmt.Sprintf("- `%s` at %s", fieldText(l), l.field.Origin()))
+	}
+	if len(contributions) > 0 {
+		fmt.Fprintf(&b, "\nDefined by:\n%s\n", strings.Join(contributions, "\n"))
+	}
+	rng := d.rangeOf(t.start, t.end)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: b.String()}, Range: &rng}
+}
+
+// fieldText renders a field on one line, shortening long values.
+func fieldText(l layer) string {
+	separator := "="
+	value := l.field.Value
+	if add, ok := value.(*raw.AddAssign); ok {
+		separator, value = "+=", add.Val
+	}
+	text := "{ ... }"
+	if _, ok := value.(*raw.Object); !ok {
+		text = strings.Join(strings.Fields(value.String()), " ")
+	}
+	if utf8.RuneCountInString(text) > 60 {
+		text = string([]rune(text)[:57]) + "..."
+	}
+	return fmt.Sprintf("%s %s %s", joinPath(l.path[l.depth:]), separator, text)
+}
+
+func joinPath(path []string) string {
+	quoted := make([]string, len(path))
+	for i, segment := range path {
+		quoted[i] = render.QuoteKey(segment)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// symbols returns the fields of a CST object as document symbols.
+func (d *document) symbols(obj *parser.CSTNode) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	for _, child := range obj.Children {
+		if child.Kind != parser.CSTField {
+			continue
+		}
+		key, value := child.Children[0], child.Children[len(child.Children)-1]
+		keyStart, keyEnd, _ := span(key)
+		_, valueEnd, ok := span(value)
+		if !ok {
+			valueEnd = keyEnd
+		}
+		symbol := DocumentSymbol{
+			Name:           strings.TrimSpace(key.Text()),
+			Kind:           valueKind(value),
+			Range:          d.rangeOf(keyStart, valueEnd),
+			SelectionRange: d.rangeOf(keyStart, keyEnd),
+		}
+		for _, part := range value.Children {
+			if part.Kind == parser.CSTObject {
+				symbol.Children = append(symbol.Children, d.symbols(part)...)
+			}
+		}
+		if symbol.Kind != SymbolKindObject && symbol.Kind != SymbolKindArray {
+			symbol.Detail = strings.TrimSpace(value.Text())
+		}
+		result = append(result, symbol)
+	}
+	return result
+}
+
+// valueKind chooses the symbol kind of a field from its value.
+func valueKind(value *parser.CSTNode) int {
+	var parts []*parser.CSTNode
+	for _, part := range value.Children {
+		if !part.Is(parser.TokenWhitespace) {
+			parts = append(parts, part)
+		}
+	}
+	for _, part := range parts {
+		if part.Kind == parser.CSTObject {
+			return SymbolKindObject
+		}
+	}
+	if len(parts) != 1 {
+		return SymbolKindString
+	}
+	part := parts[0]
+	switch {
+	case part.Kind == parser.CSTArray:
+		return SymbolKindArray
+	case part.Is(parser.TokenSubstitution):
+		return SymbolKindVariable
+	case part.Is(parser.TokenUnquoted):
+		switch part.Token.Text {
+		case "true", "false":
+			return SymbolKindBoolean
+		case "null":
+			return SymbolKindNull
+		}
+		if _, err := strconv.ParseFloat(part.Token.Text, 64); err == nil {
+			return SymbolKindNumber
+		}
+	}
+	return SymbolKindString
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// fileURI returns the URI of a file named in an origin, which may already be
+// a URL for documents that were fetched.
+func fileURI(file string) string {
+	if u, err := url.Parse(file); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		return file
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+}
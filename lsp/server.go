@@ -0,0 +1,235 @@
+// Package lsp implements a Language Server Protocol server for HOCON files.
+//
+// The server synchronizes whole documents and offers diagnostics from parsing
+// and resolution, go to definition for substitutions and includes, hover with
+// the resolved value of a setting and the fields that set it, document
+// symbols and formatting.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hocon-go/format"
+	"hocon-go/parser"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Server is a language server speaking JSON-RPC over a pair of streams.
+type Server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	opts parser.ConfigOptions
+
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer returns a server reading requests from in and writing responses
+// and notifications to out. Documents are parsed with opts, so includes are
+// looked up on its classpath and its override layers apply.
+func NewServer(in io.Reader, out io.Writer, opts parser.ConfigOptions) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		opts: opts,
+		docs: make(map[string]*document),
+	}
+}
+
+// errExit ends Serve once the client sends the exit notification.
+var errExit = errors.New("exit")
+
+// Serve handles messages until the input ends or the client exits.
+func (s *Server) Serve() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.handle(body); err != nil {
+			if err == errExit {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// handle dispatches one message. Only write failures are returned; problems
+// with a request are reported to the client.
+func (s *Server) handle(body []byte) error {
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return s.replyError(nil, &responseError{Code: codeParseError, Message: err.Error()})
+	}
+	if msg.ID == nil {
+		return s.notify(msg)
+	}
+	if msg.Method == "" {
+		return s.replyError(msg.ID, &responseError{Code: codeInvalidRequest, Message: "missing method"})
+	}
+	result, err := s.call(msg)
+	if err != nil {
+		var rpcErr *responseError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		return s.replyError(msg.ID, rpcErr)
+	}
+	return writeMessage(s.out, response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+func (s *Server) replyError(id *json.RawMessage, err *responseError) error {
+	return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+// call answers a request.
+func (s *Server) call(msg message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           syncFull,
+				DefinitionProvider:         true,
+				HoverProvider:              true,
+				DocumentSymbolProvider:     true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "hocon"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		doc, err := s.documentFor(msg.Params, &params, &params.TextDocument)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+		if location := doc.definition(params.Position); location != nil {
+			return location, nil
+		}
+		return nil, nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		doc, err := s.documentFor(msg.Params, &params, &params.TextDocument)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+		if hover := doc.hover(params.Position); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		doc, err := s.documentFor(msg.Params, &params, &params.TextDocument)
+		if err != nil || doc == nil || doc.cst == nil {
+			return nil, err
+		}
+		for _, child := range doc.cst.Children {
+			if child.Kind == parser.CSTObject {
+				return doc.symbols(child), nil
+			}
+		}
+		return []DocumentSymbol{}, nil
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		doc, err := s.documentFor(msg.Params, &params, &params.TextDocument)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+		return formatDocument(doc, params.Options), nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+// documentFor decodes params into v and returns the open document named by
+// id, or nil when it is not open.
+func (s *Server) documentFor(params json.RawMessage, v interface{}, id *TextDocumentIdentifier) (*document, error) {
+	if err := json.Unmarshal(params, v); err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return s.docs[id.URI], nil
+}
+
+// notify handles a notification. Unknown notifications are ignored, as are
+// malformed ones, which cannot be answered.
+func (s *Server) notify(msg message) error {
+	switch msg.Method {
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		item := params.TextDocument
+		return s.open(item.URI, item.Version, item.Text)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.open(params.TextDocument.URI, params.TextDocument.Version, text)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.publish(PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/didSave":
+		// A saved file may be included by the other open documents.
+		uris := make([]string, 0, len(s.docs))
+		for uri := range s.docs {
+			uris = append(uris, uri)
+		}
+		sort.Strings(uris)
+		for _, uri := range uris {
+			doc := s.docs[uri]
+			if err := s.open(uri, doc.version, doc.text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// open analyzes the text of a document and publishes its diagnostics.
+func (s *Server) open(uri string, version int, text string) error {
+	doc := newDocument(uri, version, text, s.opts)
+	s.docs[uri] = doc
+	return s.publish(PublishDiagnosticsParams{URI: uri, Version: version, Diagnostics: doc.diagnostics})
+}
+
+func (s *Server) publish(params PublishDiagnosticsParams) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+// formatDocument returns the edits formatting doc: none when it is already
+// formatted, nil when it has syntax errors.
+func formatDocument(doc *document, options FormattingOptions) []TextEdit {
+	opts := format.DefaultOptions()
+	switch {
+	case !options.InsertSpaces:
+		opts.Indent = "\t"
+	case options.TabSize > 0:
+		opts.Indent = strings.Repeat(" ", options.TabSize)
+	}
+	formatted, err := format.Source([]byte(doc.text), opts)
+	if err != nil {
+		return nil
+	}
+	if string(formatted) == doc.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: doc.rangeOf(0, len(doc.text)), NewText: string(formatted)}}
+}
@@ -0,0 +1,367 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"hocon-go/parser"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// session is a conversation with a server: requests are numbered in the order
+// they are added and the replies are looked up by that number.
+type session struct {
+	t      *testing.T
+	input  bytes.Buffer
+	nextID int
+
+	replies       map[int]json.RawMessage
+	errors        map[int]*responseError
+	notifications []notification
+}
+
+func newSession(t *testing.T) *session {
+	return &session{t: t, replies: map[int]json.RawMessage{}, errors: map[int]*responseError{}}
+}
+
+func (s *session) request(method string, params interface{}) int {
+	s.nextID++
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": s.nextID, "method": method, "params": params})
+	return s.nextID
+}
+
+func (s *session) notify(method string, params interface{}) {
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *session) send(msg interface{}) {
+	if err := writeMessage(&s.input, msg); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// run serves the queued messages and collects the output.
+func (s *session) run() {
+	s.t.Helper()
+	var out bytes.Buffer
+	if err := NewServer(&s.input, &out, parser.DefaultConfigOptions()).Serve(); err != nil {
+		s.t.Fatalf("Serve: %v", err)
+	}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		var msg struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.t.Fatal(err)
+		}
+		switch {
+		case msg.ID == nil:
+			s.notifications = append(s.notifications, notification{Method: msg.Method, Params: msg.Params})
+		case msg.Error != nil:
+			s.errors[*msg.ID] = msg.Error
+		default:
+			s.replies[*msg.ID] = msg.Result
+		}
+	}
+}
+
+func (s *session) result(id int, v interface{}) {
+	s.t.Helper()
+	if err := s.errors[id]; err != nil {
+		s.t.Fatalf("request %d failed: %v", id, err)
+	}
+	if err := json.Unmarshal(s.replies[id], v); err != nil {
+		s.t.Fatalf("request %d: %v in %s", id, err, s.replies[id])
+	}
+}
+
+// diagnostics returns the diagnostics last published for uri.
+func (s *session) diagnostics(uri string) []Diagnostic {
+	s.t.Helper()
+	var found []Diagnostic
+	for _, n := range s.notifications {
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(n.Params.(json.RawMessage), &params); err != nil {
+			s.t.Fatal(err)
+		}
+		if n.Method == "textDocument/publishDiagnostics" && params.URI == uri {
+			found = params.Diagnostics
+		}
+	}
+	return found
+}
+
+func textDocument(uri string) TextDocumentIdentifier {
+	return TextDocumentIdentifier{URI: uri}
+}
+
+func positionParams(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: textDocument(uri), Position: Position{Line: line, Character: character}}
+}
+
+func open(s *session, uri, text string) {
+	s.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "hocon", Version: 1, Text: text},
+	})
+}
+
+func TestLifecycle(t *testing.T) {
+	s := newSession(t)
+	initialize := s.request("initialize", map[string]interface{}{})
+	s.notify("initialized", map[string]interface{}{})
+	unknown := s.request("workspace/symbol", map[string]interface{}{})
+	shutdown := s.request("shutdown", nil)
+	s.notify("exit", nil)
+	s.request("shutdown", nil)
+	s.run()
+
+	var result InitializeResult
+	s.result(initialize, &result)
+	caps := result.Capabilities
+	if caps.TextDocumentSync != syncFull || !caps.DefinitionProvider || !caps.HoverProvider ||
+		!caps.DocumentSymbolProvider || !caps.DocumentFormattingProvider {
+		t.Fatalf("capabilities %+v", caps)
+	}
+	if err := s.errors[unknown]; err == nil || err.Code != codeMethodNotFound {
+		t.Fatalf("unknown method: %v", err)
+	}
+	if string(s.replies[shutdown]) != "null" {
+		t.Fatalf("shutdown result %s", s.replies[shutdown])
+	}
+	if len(s.replies)+len(s.errors) != 3 {
+		t.Fatalf("messages after exit were handled: %v %v", s.replies, s.errors)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	s := newSession(t)
+	open(s, "untitled:syntax", "a = 1\nb = 2 = 3\nc = 3 = 4\n")
+	open(s, "untitled:resolve", "a = 1\nb = ${missing}\n")
+	open(s, "untitled:fine", "a = 1\n")
+	s.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "untitled:fine", Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "a = ${b}\nb = ${a}\n"}},
+	})
+	open(s, "untitled:closed", "a = ")
+	s.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: textDocument("untitled:closed")})
+	s.run()
+
+	syntax := s.diagnostics("untitled:syntax")
+	if len(syntax) != 2 {
+		t.Fatalf("syntax diagnostics %+v", syntax)
+	}
+	if syntax[1].Range.Start != (Position{Line: 2, Character: 6}) || syntax[1].Severity != SeverityError {
+		t.Fatalf("second syntax diagnostic %+v", syntax[1])
+	}
+	resolve := s.diagnostics("untitled:resolve")
+	if len(resolve) != 1 || !strings.Contains(resolve[0].Message, "missing") ||
+		resolve[0].Range != (Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 14}}) {
+		t.Fatalf("resolution diagnostics %+v", resolve)
+	}
+	cycle := s.diagnostics("untitled:fine")
+	if len(cycle) != 1 || !strings.Contains(cycle[0].Message, "cycle") {
+		t.Fatalf("diagnostics after change %+v", cycle)
+	}
+	if closed := s.diagnostics("untitled:closed"); closed == nil || len(closed) != 0 {
+		t.Fatalf("diagnostics after close %+v", closed)
+	}
+}
+
+func TestDefinitionAndHover(t *testing.T) {
+	dir := t.TempDir()
+	common := filepath.Join(dir, "common.conf")
+	if err := os.WriteFile(common, []byte("db {\n  host = localhost\n  port = 5432\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	main := filepath.Join(dir, "main.conf")
+	uri := fileURI(main)
+	text := "include \"common.conf\"\n" +
+		"db.port = 6543\n" +
+		"url = \"postgres://\"${db.host}\":\"${db.port}\n" +
+		"name = ${db.name}\n"
+	s := newSession(t)
+	open(s, uri, text)
+	toHost := s.request("textDocument/definition", positionParams(uri, 2, 22))
+	toPort := s.request("textDocument/definition", positionParams(uri, 2, 38))
+	toInclude := s.request("textDocument/definition", positionParams(uri, 0, 3))
+	toNothing := s.request("textDocument/definition", positionParams(uri, 3, 9))
+	hoverPort := s.request("textDocument/hover", positionParams(uri, 1, 4))
+	hoverURL := s.request("textDocument/hover", positionParams(uri, 2, 1))
+	s.run()
+
+	var host, port, include Location
+	s.result(toHost, &host)
+	if host.URI != fileURI(common) || host.Range.Start != (Position{Line: 1, Character: 2}) {
+		t.Fatalf("definition of db.host %+v", host)
+	}
+	s.result(toPort, &port)
+	if port.URI != uri || port.Range.Start != (Position{Line: 1, Character: 0}) {
+		t.Fatalf("definition of db.port %+v", port)
+	}
+	s.result(toInclude, &include)
+	if include.URI != fileURI(common) {
+		t.Fatalf("definition of include %+v", include)
+	}
+	if string(s.replies[toNothing]) != "null" {
+		t.Fatalf("definition of undefined path %s", s.replies[toNothing])
+	}
+
+	var hover Hover
+	s.result(hoverPort, &hover)
+	for _, want := range []string{"**db.port**", "6543", "port = 5432", "common.conf:3", "db.port = 6543", "main.conf:2"} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Fatalf("hover on db.port lacks %q:\n%s", want, hover.Contents.Value)
+		}
+	}
+	if strings.Index(hover.Contents.Value, "5432") > strings.Index(hover.Contents.Value, "db.port = 6543") {
+		t.Fatalf("hover layers out of order:\n%s", hover.Contents.Value)
+	}
+	s.result(hoverURL, &hover)
+	if !strings.Contains(hover.Contents.Value, "**url**") || !strings.Contains(hover.Contents.Value, "Not resolved") {
+		t.Fatalf("hover on url:\n%s", hover.Contents.Value)
+	}
+}
+
+func TestSymbolsAndFormatting(t *testing.T) {
+	uri := "untitled:doc"
+	s := newSession(t)
+	open(s, uri, "app {\n  name = demo, port = 80\n  tags = [a]\n  debug = true\n}\nref = ${app.name}\n")
+	symbols := s.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: textDocument(uri)})
+	formatTabs := s.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: textDocument(uri), Options: FormattingOptions{TabSize: 4},
+	})
+	open(s, "untitled:clean", "a = 1\n")
+	formatClean := s.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: textDocument("untitled:clean"), Options: FormattingOptions{TabSize: 2, InsertSpaces: true},
+	})
+	open(s, "untitled:broken", "a = [")
+	formatBroken := s.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: textDocument("untitled:broken"), Options: FormattingOptions{TabSize: 2, InsertSpaces: true},
+	})
+	s.run()
+
+	var got []DocumentSymbol
+	s.result(symbols, &got)
+	if len(got) != 2 || got[0].Name != "app" || got[0].Kind != SymbolKindObject || got[1].Kind != SymbolKindVariable {
+		t.Fatalf("symbols %+v", got)
+	}
+	if got[0].Range != (Range{Start: Position{}, End: Position{Line: 4, Character: 1}}) {
+		t.Fatalf("range of app %+v", got[0].Range)
+	}
+	var kinds []int
+	for _, child := range got[0].Children {
+		kinds = append(kinds, child.Kind)
+	}
+	want := []int{SymbolKindString, SymbolKindNumber, SymbolKindArray, SymbolKindBoolean}
+	if len(kinds) != len(want) {
+		t.Fatalf("children of app %+v", got[0].Children)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("children of app %+v", got[0].Children)
+		}
+	}
+
+	var edits []TextEdit
+	s.result(formatTabs, &edits)
+	if len(edits) != 1 || edits[0].NewText != "app {\n\tname = demo\n\tport = 80\n\ttags = [a]\n\tdebug = true\n}\nref = ${app.name}\n" ||
+		edits[0].Range.End != (Position{Line: 6, Character: 0}) {
+		t.Fatalf("formatting edits %+v", edits)
+	}
+	s.result(formatClean, &edits)
+	if edits == nil || len(edits) != 0 {
+		t.Fatalf("formatting a clean document %+v", edits)
+	}
+	if string(s.replies[formatBroken]) != "null" {
+		t.Fatalf("formatting a broken document %s", s.replies[formatBroken])
+	}
+}
+
+func TestPositions(t *testing.T) {
+	d := &document{text: "a = \"é😀\"\nb = 1", lines: []int{0, 12}}
+	if pos := d.position(11); pos != (Position{Line: 0, Character: 8}) {
+		t.Fatalf("position(11) = %+v", pos)
+	}
+	if offset := d.offset(Position{Line: 0, Character: 8}); offset != 11 {
+		t.Fatalf("offset = %d", offset)
+	}
+	if offset := d.offset(Position{Line: 1, Character: 40}); offset != len(d.text) {
+		t.Fatalf("offset past end of line = %d", offset)
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	uri := "untitled:split"
+	s := newSession(t)
+	open(s, uri, "a =\n  1\nb\n{ c = 2 }\n")
+	hover := s.request("textDocument/hover", positionParams(uri, 0, 0))
+	symbols := s.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: textDocument(uri)})
+	formatting := s.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: textDocument(uri), Options: FormattingOptions{TabSize: 2, InsertSpaces: true},
+	})
+	s.run()
+
+	if diags := s.diagnostics(uri); len(diags) != 0 {
+		t.Fatalf("diagnostics %+v", diags)
+	}
+	var h Hover
+	s.result(hover, &h)
+	if !strings.Contains(h.Contents.Value, "**a**") || !strings.Contains(h.Contents.Value, "1") {
+		t.Fatalf("hover:\n%s", h.Contents.Value)
+	}
+	var got []DocumentSymbol
+	s.result(symbols, &got)
+	if len(got) != 2 || got[0].Name != "a" || got[0].Kind != SymbolKindNumber || got[1].Kind != SymbolKindObject {
+		t.Fatalf("symbols %+v", got)
+	}
+	var edits []TextEdit
+	s.result(formatting, &edits)
+	if len(edits) != 1 || edits[0].NewText != "a = 1\nb {\n  c = 2\n}\n" {
+		t.Fatalf("formatting edits %+v", edits)
+	}
+}
+
+func TestIncludeDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"broken.conf":     "x = ]\n",
+		"unresolved.conf": "y = ${nowhere}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	syntaxURI := fileURI(filepath.Join(dir, "syntax.conf"))
+	resolveURI := fileURI(filepath.Join(dir, "resolve.conf"))
+	s := newSession(t)
+	open(s, syntaxURI, "a = 1\ninclude \"broken.conf\"\n")
+	open(s, resolveURI, "a = 1\nnested {\n  include \"unresolved.conf\"\n}\n")
+	s.run()
+
+	syntax := s.diagnostics(syntaxURI)
+	if len(syntax) != 1 || !strings.Contains(syntax[0].Message, "broken.conf:1:5") ||
+		syntax[0].Range != (Range{Start: Position{Line: 1, Character: 0}, End: Position{Line: 1, Character: 21}}) {
+		t.Fatalf("syntax diagnostics %+v", syntax)
+	}
+	resolve := s.diagnostics(resolveURI)
+	if len(resolve) != 1 || !strings.Contains(resolve[0].Message, "unresolved.conf:1:5") ||
+		resolve[0].Range != (Range{Start: Position{Line: 2, Character: 2}, End: Position{Line: 2, Character: 27}}) {
+		t.Fatalf("resolution diagnostics %+v", resolve)
+	}
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type AddAssign struct {
+	common.Located
 	Val Value
 }
 
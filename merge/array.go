@@ -1,10 +1,12 @@
 package merge
 
 import (
+	"hocon-go/common"
 	"hocon-go/raw"
 )
 
 type Array struct {
+	common.Located
 	Values   []Value
 	IsMerged bool
 }
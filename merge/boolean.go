@@ -1,11 +1,13 @@
 package merge
 
 import (
+	"hocon-go/common"
 	"hocon-go/raw"
 	"strconv"
 )
 
 type Boolean struct {
+	common.Located
 	Val bool
 }
 
@@ -1,6 +1,7 @@
 package merge
 
 import (
+	"errors"
 	"fmt"
 	"hocon-go/common"
 	"hocon-go/raw"
@@ -9,6 +10,7 @@ import (
 // Concat represents a concatenation of evaluated HOCON values during the merge phase.
 // Similar to Rust's Concat, but uses Go slices and pointers instead of VecDeque and RefCell.
 type Concat struct {
+	common.Located
 	values []Value   // slice of value pointers
 	spaces []*string // slice of optional spaces; len(values) == len(spaces)+1
 }
@@ -126,6 +128,11 @@ func (c *Concat) TryResolve(path *common.Path) (Value, error) {
 			var err error
 			result, err = Concatenate(path, result, space, second)
 			if err != nil {
+				// Point at the concatenation itself rather than at one of its parts.
+				var mixErr *common.ConcatenateDifferentType
+				if errors.As(err, &mixErr) && c.Origin() != nil && path != nil && mixErr.Path == path.String() {
+					mixErr.Origin = c.Origin()
+				}
 				return nil, err
 			}
 			space = secondSpace
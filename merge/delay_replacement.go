@@ -1,9 +1,12 @@
 package merge
 
+import "hocon-go/common"
+
 // DelayReplacement is a container for values that cannot be immediately merged during a replacement operation.
 // When merging HOCON values, a substitution expression (${...}) might be encountered. Since the final value
 // of the substitution is unknown until the entire configuration is parsed, these pending values are stored here.
 type DelayReplacement struct {
+	common.Located
 	Values []Value
 }
 
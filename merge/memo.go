@@ -1,6 +1,67 @@
 package merge
 
+import "hocon-go/common"
+
 type Memo struct {
 	Tracker             []string
 	SubstitutionCounter int
+	Options             ResolveOptions
+	// previous holds, innermost last, the values that the fields being
+	// defined had before their current definition. A field with no earlier
+	// definition records None.
+	previous []previousValue
+}
+
+// previousValue is the value a field had before the definition being resolved.
+type previousValue struct {
+	path  *common.Path
+	value Value
+}
+
+func (m *Memo) pushPrevious(path *common.Path, value Value) {
+	m.previous = append(m.previous, previousValue{path: path, value: value})
+}
+
+func (m *Memo) popPrevious() {
+	m.previous = m.previous[:len(m.previous)-1]
+}
+
+// lookupPrevious finds a self-reference: a substitution of a field being
+// defined, or of a path inside it. The second result reports whether path is
+// such a reference; the value is nil when the earlier value has no such path.
+func (m *Memo) lookupPrevious(path *common.Path) (Value, bool) {
+	for i := len(m.previous) - 1; i >= 0; i-- {
+		entry := m.previous[i]
+		remainder, ok := trimPathPrefix(path, entry.path)
+		if !ok {
+			continue
+		}
+		if _, isNone := entry.value.(*None); isNone {
+			return nil, true
+		}
+		value, found := getValueFromPath(entry.value, remainder)
+		if !found {
+			return nil, true
+		}
+		return value, true
+	}
+	return nil, false
+}
+
+// ResolveOptions controls how substitutions are resolved.
+type ResolveOptions struct {
+	// Sources are consulted in order for substitutions not defined in the
+	// configuration. Without sources such substitutions are unresolved.
+	Sources []common.SubstitutionSource
+	// Trace, when set, records every substitution that is resolved.
+	Trace *Trace
+}
+
+func (o ResolveOptions) lookup(path string) (string, bool) {
+	for _, source := range o.Sources {
+		if val, ok := source.Lookup(path); ok {
+			return val, true
+		}
+	}
+	return "", false
 }
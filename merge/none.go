@@ -1,6 +1,10 @@
 package merge
 
-type None struct{}
+import "hocon-go/common"
+
+type None struct {
+	common.Located
+}
 
 func (o *None) Type() string {
 	return "none"
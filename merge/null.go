@@ -1,6 +1,10 @@
 package merge
 
-type Null struct{}
+import "hocon-go/common"
+
+type Null struct {
+	common.Located
+}
 
 func (*Null) Type() string {
 	return "null"
@@ -1,8 +1,12 @@
 package merge
 
-import "hocon-go/raw"
+import (
+	"hocon-go/common"
+	"hocon-go/raw"
+)
 
 type Number struct {
+	common.Located
 	N raw.Number
 }
 
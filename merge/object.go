@@ -4,15 +4,73 @@ import (
 	"fmt"
 	"hocon-go/common"
 	"hocon-go/raw"
-	"os"
+	"sort"
 	"strings"
 )
 
 const maxSubstitutionDepth = 32
 
 type Object struct {
+	common.Located
+	// Values holds the fields by key. New keys should be added with Set so that
+	// their definition order is recorded.
 	Values   map[string]Value
 	IsMerged bool
+	// order lists the keys in definition order.
+	order []string
+}
+
+// Keys returns the keys in definition order. A key that is overridden keeps
+// the position of its first definition. Keys stored into Values directly,
+// without Set, follow in lexical order.
+func (o *Object) Keys() []string {
+	keys := make([]string, 0, len(o.Values))
+	for _, k := range o.order {
+		if _, ok := o.Values[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(o.Values) {
+		return keys
+	}
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k] = true
+	}
+	var extra []string
+	for k := range o.Values {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// Set stores value under key, appending the key to the definition order when
+// it is new.
+func (o *Object) Set(key string, value Value) {
+	if o.Values == nil {
+		o.Values = make(map[string]Value)
+	}
+	if _, ok := o.Values[key]; !ok {
+		o.order = append(o.order, key)
+	}
+	o.Values[key] = value
+}
+
+// Delete removes key from the object.
+func (o *Object) Delete(key string) {
+	if _, ok := o.Values[key]; !ok {
+		return
+	}
+	delete(o.Values, key)
+	for i, k := range o.order {
+		if k == key {
+			o.order = append(o.order[:i:i], o.order[i+1:]...)
+			break
+		}
+	}
 }
 
 func (o *Object) Type() string {
@@ -24,19 +82,30 @@ func (o *Object) String() string {
 		return "{}"
 	}
 	parts := make([]string, 0, len(o.Values))
-	for k, v := range o.Values {
-		parts = append(parts, fmt.Sprintf("%s=%s", k, v.String()))
+	for _, k := range o.Keys() {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, o.Values[k].String()))
 	}
 	return "{" + strings.Join(parts, ",") + "}"
 }
 
 func (o *Object) isMergeValue() {}
 
+// NewObject returns an object holding values. A map has no order, so its
+// keys are added in lexical order; use Set to add keys in definition order.
 func NewObject(values map[string]Value, isMerged bool) *Object {
-	return &Object{
-		Values:   values,
+	obj := &Object{
+		Values:   make(map[string]Value, len(values)),
 		IsMerged: isMerged,
 	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		obj.Set(k, values[k])
+	}
+	return obj
 }
 
 // Merge merges another Object into the current one.
@@ -46,8 +115,10 @@ func (o *Object) Merge(other *Object, parent *common.Path) error {
 	// Determine whether both sides were merged
 	bothMerged := o.IsMerged && other.IsMerged
 
-	// Iterate over keys in right-hand object
-	for k, vRight := range other.Values {
+	// Iterate over keys in right-hand object, in definition order so that new
+	// keys are appended in the order they were written.
+	for _, k := range other.Keys() {
+		vRight := other.Values[k]
 		var subPath *common.Path
 		if parent == nil {
 			subPath = common.NewPath(common.NewStrKey(k), nil)
@@ -93,7 +164,7 @@ func (o *Object) Merge(other *Object, parent *common.Path) error {
 			if obj, ok := replaced.(*Object); ok {
 				obj.ResolveAddAssign()
 			}
-			o.Values[k] = replaced
+			o.Set(k, replaced)
 		}
 	}
 
@@ -129,18 +200,19 @@ func (o *Object) TryBecomeMerged() {
 	o.IsMerged = true
 }
 
-func (o *Object) Substitute() error {
+func (o *Object) Substitute(opts ResolveOptions) error {
 	if o == nil {
 		return nil
 	}
-	memo := &Memo{}
-	for key, val := range o.Values {
+	memo := &Memo{Options: opts}
+	for _, key := range o.Keys() {
+		val := o.Values[key]
 		path := common.NewPath(common.NewStrKey(key), nil)
-		resolved, err := o.substituteValue(path, val, memo)
+		resolved, err := o.resolveField(path, val, memo)
 		if err != nil {
 			return err
 		}
-		o.Values[key] = resolved
+		setResolved(o, key, resolved)
 	}
 	o.TryBecomeMerged()
 	return nil
@@ -158,13 +230,14 @@ func (o *Object) substituteValue(path *common.Path, value Value, memo *Memo) (Va
 
 	switch v := value.(type) {
 	case *Object:
-		for key, child := range v.Values {
+		for _, key := range v.Keys() {
+			child := v.Values[key]
 			subPath := appendPath(path, common.NewStrKey(key))
-			resolved, err := o.substituteValue(subPath, child, memo)
+			resolved, err := o.resolveField(subPath, child, memo)
 			if err != nil {
 				return nil, err
 			}
-			v.Values[key] = resolved
+			setResolved(v, key, resolved)
 		}
 		v.TryBecomeMerged()
 		return v, nil
@@ -185,25 +258,60 @@ func (o *Object) substituteValue(path *common.Path, value Value, memo *Memo) (Va
 	}
 }
 
+// setResolved stores the resolved value of a field. A field whose value is a
+// missing optional substitution is not created at all.
+func setResolved(obj *Object, key string, value Value) {
+	if _, isNone := value.(*None); isNone {
+		obj.Delete(key)
+		return
+	}
+	obj.Values[key] = value
+}
+
+// handleArray resolves the elements of an array. A substitution inside an
+// array is not a self-reference: the ${a} of a = [${a}] refers to the array
+// being defined, a cycle, rather than to the earlier value of a.
 func (o *Object) handleArray(path *common.Path, array *Array, memo *Memo) (Value, error) {
+	saved := memo.previous
+	memo.previous = nil
+	defer func() { memo.previous = saved }()
+	return o.resolveElements(path, array, memo)
+}
+
+// resolveElements resolves the elements of an array in place. Elements that
+// are missing optional substitutions are left out.
+func (o *Object) resolveElements(path *common.Path, array *Array, memo *Memo) (Value, error) {
 	allMerged := true
+	values := array.Values[:0]
 	for idx, element := range array.Values {
 		subPath := appendPath(path, common.NewIndexKey(uint(idx)))
 		resolved, err := o.substituteValue(subPath, element, memo)
 		if err != nil {
 			return nil, err
 		}
-		array.Values[idx] = resolved
+		if _, isNone := resolved.(*None); isNone {
+			continue
+		}
+		values = append(values, resolved)
 		if !IsMerged(resolved) {
 			allMerged = false
 		}
 	}
+	array.Values = values
 	array.IsMerged = allMerged
 	return array, nil
 }
 
+// handleAddAssign resolves the value appended by +=. As a += b stands for
+// a = ${?a} [b], the substitutions in b see the earlier value of a.
 func (o *Object) handleAddAssign(path *common.Path, add *AddAssign, memo *Memo) (Value, error) {
-	resolved, err := o.substituteValue(path, add.Val, memo)
+	var resolved Value
+	var err error
+	if array, ok := add.Val.(*Array); ok {
+		resolved, err = o.resolveElements(path, array, memo)
+	} else {
+		resolved, err = o.substituteValue(path, add.Val, memo)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -211,39 +319,73 @@ func (o *Object) handleAddAssign(path *common.Path, add *AddAssign, memo *Memo)
 	return add, nil
 }
 
+// resolveField resolves the value of the field at path. Unless the value is
+// an object, whose fields are resolved one by one, or a DelayReplacement,
+// which records its own earlier values, the field has no earlier value that a
+// self-reference could refer to.
+func (o *Object) resolveField(path *common.Path, value Value, memo *Memo) (Value, error) {
+	switch value.(type) {
+	case *Object, *DelayReplacement:
+		return o.substituteValue(path, value, memo)
+	}
+	memo.pushPrevious(path, &None{})
+	defer memo.popPrevious()
+	return o.substituteValue(path, value, memo)
+}
+
 func (o *Object) handleSubstitution(path *common.Path, substitution *Substitution, memo *Memo) (Value, error) {
 	if err := pushTrackerPath(memo, path); err != nil {
+		if cycle, ok := err.(*common.SubstitutionCycle); ok {
+			cycle.Origin = substitution.Origin()
+		}
 		return nil, err
 	}
 	defer popTrackerPath(memo)
 
-	if target, ok := o.getValueByPath(substitution.Path); ok {
-		if pathsEqual(substitution.Path, path) {
-			if _, isSub := target.(*Substitution); isSub {
-				if substitution.Optional {
-					return &None{}, nil
-				}
-				return nil, &common.SubstitutionCycle{
-					Current:   substitution.String(),
-					Backtrace: []string{substitution.String()},
-				}
-			}
+	for _, target := range substitution.candidates() {
+		// A field referring to itself sees the value it had before, and
+		// falls back to the other candidates and the substitution sources
+		// when there is none.
+		previous, isSelfReference := memo.lookupPrevious(target)
+		if previous != nil {
+			value := CloneValue(previous)
+			memo.Options.Trace.substituted(SubstitutionStep{Path: path, Substitution: substitution, Target: target, SelfReference: true, Value: value})
+			return value, nil
 		}
-		clone := CloneValue(target)
-		resolved, err := o.substituteValue(clonePath(substitution.Path), clone, memo)
+		if isSelfReference {
+			continue
+		}
+		resolved, found, err := o.findTarget(target, memo)
 		if err != nil {
 			return nil, err
 		}
-		return resolved, nil
+		if found {
+			memo.Options.Trace.substituted(SubstitutionStep{Path: path, Substitution: substitution, Target: target, Value: resolved})
+			return resolved, nil
+		}
 	}
 
-	if envVal, ok := os.LookupEnv(substitution.FullPath()); ok {
-		return NewString(envVal), nil
+	if val, ok := memo.Options.lookup(substitution.FullPath()); ok {
+		str := NewString(val)
+		str.SetOrigin(substitution.Origin())
+		memo.Options.Trace.substituted(SubstitutionStep{Path: path, Substitution: substitution, Value: str})
+		return str, nil
 	}
 	if substitution.Optional {
+		memo.Options.Trace.substituted(SubstitutionStep{Path: path, Substitution: substitution})
 		return &None{}, nil
 	}
-	return nil, &common.SubstitutionNotFound{Path: substitution.FullPath()}
+	return nil, &common.SubstitutionNotFound{Path: substitution.FullPath(), Origin: substitution.Origin()}
+}
+
+// resolveTarget resolves the value a substitution refers to as a field of its
+// own; the earlier values of the fields around the substitution do not apply
+// to it.
+func (o *Object) resolveTarget(path *common.Path, value Value, memo *Memo) (Value, error) {
+	saved := memo.previous
+	memo.previous = nil
+	defer func() { memo.previous = saved }()
+	return o.resolveField(clonePath(path), CloneValue(value), memo)
 }
 
 func (o *Object) handleConcat(path *common.Path, concat *Concat, memo *Memo) (Value, error) {
@@ -258,45 +400,57 @@ func (o *Object) handleConcat(path *common.Path, concat *Concat, memo *Memo) (Va
 	return concat.TryResolve(path)
 }
 
+// handleDelayReplacement resolves the successive definitions of a field from
+// first to last. Each definition is resolved with the result of the earlier
+// ones as the field's previous value, so that ${path} inside it refers to
+// that value rather than to the final one.
 func (o *Object) handleDelayReplacement(path *common.Path, delay *DelayReplacement, memo *Memo) (Value, error) {
-	for idx, element := range delay.Values {
-		subPath := appendPath(path, common.NewIndexKey(uint(idx)))
-		resolved, err := o.substituteValue(subPath, element, memo)
+	var result Value = &None{}
+	for _, element := range delay.Values {
+		memo.pushPrevious(path, result)
+		resolved, err := o.substituteValue(path, element, memo)
+		memo.popPrevious()
 		if err != nil {
 			return nil, err
 		}
-		delay.Values[idx] = resolved
-	}
-	if len(delay.Values) == 0 {
-		return &None{}, nil
-	}
-	result := delay.Values[len(delay.Values)-1]
-	for i := len(delay.Values) - 2; i >= 0; i-- {
-		var err error
-		result, err = Replace(path, delay.Values[i], result)
-		if err != nil {
+		if result, err = Replace(path, result, resolved); err != nil {
 			return nil, err
 		}
 	}
+	if obj, ok := result.(*Object); ok {
+		obj.ResolveAddAssign()
+	}
 	return result, nil
 }
 
-func (o *Object) getValueByPath(path *common.Path) (Value, bool) {
-	if path == nil {
-		return nil, false
-	}
-	key, ok := path.First.(*common.StrKey)
-	if !ok {
-		return nil, false
-	}
-	val, ok := o.Values[key.Str]
-	if !ok {
-		return nil, false
+// findTarget returns the resolved value at path, and whether there is one.
+// A value on the way that is not resolved yet, such as a concatenation of
+// objects or a substitution, is resolved first, so that the rest of the path
+// is looked up in its result rather than in its parts.
+func (o *Object) findTarget(path *common.Path, memo *Memo) (Value, bool, error) {
+	var current Value = o
+	var prefix *common.Path
+	for p := path; p != nil; p = p.Remainder {
+		switch current.(type) {
+		case *Substitution, *Concat, *DelayReplacement, *AddAssign:
+			resolved, err := o.resolveTarget(prefix, current, memo)
+			if err != nil {
+				return nil, false, err
+			}
+			current = resolved
+		}
+		child, ok := getValueFromPath(current, common.NewPath(p.First, nil))
+		if !ok {
+			return nil, false, nil
+		}
+		current = child
+		prefix = appendPath(prefix, p.First)
 	}
-	if path.Remainder == nil {
-		return val, true
+	resolved, err := o.resolveTarget(path, current, memo)
+	if err != nil {
+		return nil, false, err
 	}
-	return getValueFromPath(val, path.Remainder)
+	return resolved, true, nil
 }
 
 func getValueFromPath(val Value, path *common.Path) (Value, bool) {
@@ -325,9 +479,6 @@ func getValueFromPath(val Value, path *common.Path) (Value, bool) {
 		}
 		return getValueFromPath(v.Values[idx], path.Remainder)
 	default:
-		if path.Remainder == nil {
-			return val, true
-		}
 		return nil, false
 	}
 }
@@ -25,6 +25,14 @@ func appendPath(path *common.Path, key common.Key) *common.Path {
 	return clone
 }
 
+// joinPaths returns a new path made of prefix followed by path.
+func joinPaths(prefix, path *common.Path) *common.Path {
+	if prefix == nil {
+		return clonePath(path)
+	}
+	return &common.Path{First: cloneKey(prefix.First), Remainder: joinPaths(prefix.Remainder, path)}
+}
+
 func pathsEqual(a, b *common.Path) bool {
 	if a == nil && b == nil {
 		return true
@@ -61,3 +69,15 @@ func keysEqual(a, b common.Key) bool {
 		return a == b
 	}
 }
+
+// trimPathPrefix returns the part of path after prefix, and whether prefix is
+// a prefix of path. The remainder is nil when the paths are equal.
+func trimPathPrefix(path, prefix *common.Path) (*common.Path, bool) {
+	for prefix != nil {
+		if path == nil || !keysEqual(path.First, prefix.First) {
+			return nil, false
+		}
+		path, prefix = path.Remainder, prefix.Remainder
+	}
+	return path, true
+}
@@ -1,6 +1,9 @@
 package merge
 
+import "hocon-go/common"
+
 type String struct {
+	common.Located
 	Val string
 }
 
@@ -6,8 +6,13 @@ import (
 )
 
 type Substitution struct {
+	common.Located
 	Path     *common.Path
 	Optional bool
+	// Prefix is the path the included file containing the substitution is
+	// mounted at, or nil outside included files. Path is looked up under
+	// Prefix first and from the root second.
+	Prefix *common.Path
 }
 
 func NewSubstitution(path *common.Path, optional bool) *Substitution {
@@ -40,3 +45,11 @@ func (s *Substitution) FullPath() string {
 	}
 	return s.Path.String()
 }
+
+// candidates returns the paths the substitution is looked up at, in order.
+func (s *Substitution) candidates() []*common.Path {
+	if s.Prefix == nil {
+		return []*common.Path{s.Path}
+	}
+	return []*common.Path{joinPaths(s.Prefix, s.Path), s.Path}
+}
@@ -0,0 +1,71 @@
+package merge
+
+import (
+	"hocon-go/common"
+	"hocon-go/raw"
+)
+
+// Trace records how a configuration was built and resolved: every field in
+// the order the fields were merged, including the ones later fields
+// override, and every substitution resolved along the way. A nil *Trace
+// records nothing.
+type Trace struct {
+	Definitions   []Definition
+	Substitutions []SubstitutionStep
+
+	seen map[string]bool
+}
+
+// Definition is a field that set Path to Value.
+type Definition struct {
+	Path   *common.Path
+	Value  raw.Value
+	Origin *common.Origin
+}
+
+// SubstitutionStep is a substitution resolved for the value at Path. The
+// parts of concatenations and arrays have their index appended to Path.
+type SubstitutionStep struct {
+	Path         *common.Path
+	Substitution *Substitution
+	// Target is the path the value was found at. It is nil when the value
+	// came from a substitution source, or when the substitution was optional
+	// and had no value, in which case Value is nil too.
+	Target *common.Path
+	// SelfReference reports that Target is being defined and Value is the
+	// value it had before.
+	SelfReference bool
+	Value         Value
+}
+
+// Define records a field setting path to value.
+func (t *Trace) Define(path *common.Path, value raw.Value, origin *common.Origin) {
+	if t == nil {
+		return
+	}
+	t.Definitions = append(t.Definitions, Definition{Path: clonePath(path), Value: value, Origin: origin})
+}
+
+// substituted records a resolved substitution. The value a substitution
+// refers to is resolved again wherever it is used, so a substitution at the
+// same place is recorded once.
+func (t *Trace) substituted(step SubstitutionStep) {
+	if t == nil {
+		return
+	}
+	key := step.Path.String() + " " + step.Substitution.String() + " " + step.Substitution.Origin().String()
+	if t.seen[key] {
+		return
+	}
+	if t.seen == nil {
+		t.seen = make(map[string]bool)
+	}
+	t.seen[key] = true
+	step.Path = clonePath(step.Path)
+	step.Target = clonePath(step.Target)
+	if _, isNone := step.Value.(*None); isNone {
+		step.Value = nil
+	}
+	step.Value = CloneValue(step.Value)
+	t.Substitutions = append(t.Substitutions, step)
+}
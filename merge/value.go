@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"hocon-go/common"
 	"hocon-go/raw"
-	"log"
 )
 
 type Value interface {
 	Type() string
 	String() string
+	Origin() *common.Origin
+	SetOrigin(origin *common.Origin)
 	isMergeValue()
 }
 
@@ -39,13 +40,14 @@ func Replace(path *common.Path, left Value, right Value) (Value, error) {
 				}
 				return l, nil
 			case *Concat:
-				rr.PushFront(left, nil)
-				return rr, nil
+				// The concatenation may refer to the object through a
+				// substitution, so it is resolved on top of it later.
+				return NewDelayReplacement([]Value{left, rr}), nil
 			default:
 				return rr, nil
 			}
 		case *AddAssign:
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: "object", RightType: r.Type()}
+			return nil, concatenateError(path, left, right)
 		case *DelayReplacement:
 			r.PushFront(left)
 			return r, nil
@@ -72,12 +74,12 @@ func Replace(path *common.Path, left Value, right Value) (Value, error) {
 				return rr, nil
 			}
 		case *AddAssign:
-			inner := r.Val
-			isUnmerged := !IsMerged(inner)
-			l.Values = append(l.Values, inner)
-			if isUnmerged {
-				l.IsMerged = false
+			if !IsMerged(r.Val) {
+				// The appended value may refer to the array through a
+				// substitution, so it is appended once resolved.
+				return NewDelayReplacement([]Value{left, right}), nil
 			}
+			l.Values = append(l.Values, r.Val)
 			return l, nil
 		default:
 			return right, nil
@@ -86,7 +88,7 @@ func Replace(path *common.Path, left Value, right Value) (Value, error) {
 	// LEFT = NULL
 	case *Null:
 		if _, ok := right.(*AddAssign); ok {
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: l.Type(), RightType: right.Type()}
+			return nil, concatenateError(path, left, right)
 		}
 		return right, nil
 
@@ -98,6 +100,7 @@ func Replace(path *common.Path, left Value, right Value) (Value, error) {
 				return nil, err
 			}
 			arr := NewArray([]Value{val}, IsMerged(val))
+			arr.SetOrigin(add.Origin())
 			return arr, nil
 		}
 		return right, nil
@@ -116,19 +119,19 @@ func Replace(path *common.Path, left Value, right Value) (Value, error) {
 			case *Concat:
 				return NewDelayReplacement([]Value{left, rr}), nil
 			case *AddAssign:
-				return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: rr.Type()}
+				return nil, concatenateError(path, left, rr)
 			default:
 				return rr, nil
 			}
 		case *AddAssign:
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
+			return nil, concatenateError(path, left, right)
 		default:
 			return right, nil
 		}
 
 	// LEFT = ADDASSIGN (should not happen)
 	case *AddAssign:
-		return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
+		return nil, concatenateError(path, left, right)
 
 	// LEFT = SUBSTITUTION | CONCAT | DELAY_REPLACEMENT
 	case *Substitution, *Concat, *DelayReplacement:
@@ -150,9 +153,13 @@ func ResolveAddAssign(k string, values map[string]Value) {
 	}
 }
 
+// Concatenate joins the value left with the value right that follows it in a
+// concatenation, separated by space when the two were written apart. Objects
+// are merged with objects and arrays joined with arrays; whitespace only
+// matters between simple values, which are joined into a string. Mixing an
+// object or array with any other kind of value is an error. Substitutions
+// that are not resolved yet are kept in a Concat.
 func Concatenate(path *common.Path, left Value, space *string, right Value) (Value, error) {
-	log.Printf("concatenate: %v <- %v", left, right)
-
 	var val Value
 
 	switch l := left.(type) {
@@ -166,41 +173,53 @@ func Concatenate(path *common.Path, left Value, space *string, right Value) (Val
 				return nil, err
 			}
 			val = l
-		case *Null, *Array, *Boolean, *String, *Number, *AddAssign:
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
 		case *Substitution, *DelayReplacement:
 			val = NewConcatTwo(left, space, right)
 		case *Concat:
 			r.PushFront(left, space)
 			val = r
 		default:
-			return nil, fmt.Errorf("unsupported right type: %T", right)
+			return nil, concatenateError(path, left, right)
 		}
 
 	// --- Array concatenation ---
 	case *Array:
-		if r, ok := right.(*Array); ok {
+		switch r := right.(type) {
+		case *None:
+			val = l
+		case *Array:
 			l.Values = append(l.Values, r.Values...)
+			l.IsMerged = l.IsMerged && r.IsMerged
 			val = l
-		} else {
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
+		case *Substitution, *DelayReplacement:
+			val = NewConcatTwo(left, space, right)
+		case *Concat:
+			r.PushFront(left, space)
+			val = r
+		default:
+			return nil, concatenateError(path, left, right)
 		}
 
-	// --- None ---
+	// --- None, a missing optional substitution ---
 	case *None:
-		if space != nil {
-			switch r := right.(type) {
-			case *Null, *Boolean, *String, *Number:
-				s := *space + r.String()
-				val = NewString(s)
-			case *None:
-				val = NewString(*space)
-			case *Substitution:
-				val = NewConcatTwo(left, space, right)
-			default:
+		switch r := right.(type) {
+		case *Null, *Boolean, *String, *Number, *None:
+			if space == nil {
 				val = right
+				break
 			}
-		} else {
+			// The whitespace after a missing value is still part of the string.
+			s := *space
+			if _, isNone := r.(*None); !isNone {
+				s += r.String()
+			}
+			val = NewString(s)
+		case *Substitution, *DelayReplacement:
+			val = NewConcatTwo(left, space, right)
+		case *Concat:
+			r.PushFront(left, space)
+			val = r
+		default:
 			val = right
 		}
 
@@ -220,10 +239,13 @@ func Concatenate(path *common.Path, left Value, space *string, right Value) (Val
 				s += *space
 			}
 			val = NewString(s)
-		case *Substitution:
+		case *Substitution, *DelayReplacement:
 			val = NewConcatTwo(left, space, right)
+		case *Concat:
+			r.PushFront(left, space)
+			val = r
 		default:
-			return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
+			return nil, concatenateError(path, left, right)
 		}
 
 	// --- Substitution or DelayReplacement ---
@@ -237,51 +259,67 @@ func Concatenate(path *common.Path, left Value, space *string, right Value) (Val
 
 	// --- AddAssign (invalid) ---
 	case *AddAssign:
-		return nil, &common.ConcatenateDifferentType{Path: path.String(), LeftType: left.Type(), RightType: right.Type()}
+		return nil, concatenateError(path, left, right)
 
 	default:
 		return nil, fmt.Errorf("unknown left type: %T", left)
 	}
 
-	log.Printf("concatenate result: %v = %v", path, val)
+	if val != left && val != right && val.Origin() == nil {
+		if left.Origin() != nil {
+			val.SetOrigin(left.Origin())
+		} else {
+			val.SetOrigin(right.Origin())
+		}
+	}
 	return val, nil
 }
 
+// concatenateError reports values that cannot be concatenated, positioned at
+// the value on the right, where the concatenation goes wrong.
+func concatenateError(path *common.Path, left, right Value) error {
+	origin := right.Origin()
+	if origin == nil {
+		origin = left.Origin()
+	}
+	return &common.ConcatenateDifferentType{
+		Path:      path.String(),
+		LeftType:  left.Type(),
+		RightType: right.Type(),
+		Origin:    origin,
+	}
+}
+
 func IsMerged(value Value) bool {
 	switch val := value.(type) {
 	case *Object:
 		return val.IsMerged
 	case *Array:
 		return val.IsMerged
-	case *Boolean:
-	case *String:
-	case *Number:
-	case *Null:
-	case *None:
+	case *Boolean, *String, *Number, *Null, *None:
 		return true
-	case *Substitution:
-	case *Concat:
-	case *AddAssign:
-	case *DelayReplacement:
+	default:
 		return false
 	}
-	return false
 }
 
 func CloneValue(value Value) Value {
 	if value == nil {
 		return nil
 	}
+	clone := cloneValue(value)
+	clone.SetOrigin(value.Origin())
+	return clone
+}
+
+func cloneValue(value Value) Value {
 	switch v := value.(type) {
 	case *Object:
-		copied := make(map[string]Value, len(v.Values))
-		for k, child := range v.Values {
-			copied[k] = CloneValue(child)
-		}
-		return &Object{
-			Values:   copied,
-			IsMerged: v.IsMerged,
+		copied := &Object{IsMerged: v.IsMerged, Values: make(map[string]Value, len(v.Values))}
+		for _, k := range v.Keys() {
+			copied.Set(k, CloneValue(v.Values[k]))
 		}
+		return copied
 	case *Array:
 		values := make([]Value, len(v.Values))
 		for i, child := range v.Values {
@@ -305,6 +343,7 @@ func CloneValue(value Value) Value {
 		return &Substitution{
 			Path:     clonePath(v.Path),
 			Optional: v.Optional,
+			Prefix:   clonePath(v.Prefix),
 		}
 	case *Concat:
 		values := make([]Value, len(v.values))
@@ -0,0 +1,656 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind classifies the tokens of a concrete syntax tree.
+type TokenKind int
+
+const (
+	TokenWhitespace TokenKind = iota
+	TokenNewline
+	TokenComment
+	TokenLeftBrace
+	TokenRightBrace
+	TokenLeftBracket
+	TokenRightBracket
+	TokenComma
+	TokenColon
+	TokenEquals
+	TokenPlusEquals
+	TokenQuotedString
+	TokenMultilineString
+	TokenUnquoted
+	TokenSubstitution
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenWhitespace:
+		return "whitespace"
+	case TokenNewline:
+		return "newline"
+	case TokenComment:
+		return "comment"
+	case TokenLeftBrace:
+		return "'{'"
+	case TokenRightBrace:
+		return "'}'"
+	case TokenLeftBracket:
+		return "'['"
+	case TokenRightBracket:
+		return "']'"
+	case TokenComma:
+		return "','"
+	case TokenColon:
+		return "':'"
+	case TokenEquals:
+		return "'='"
+	case TokenPlusEquals:
+		return "'+='"
+	case TokenQuotedString:
+		return "quoted string"
+	case TokenMultilineString:
+		return "multi-line string"
+	case TokenUnquoted:
+		return "unquoted text"
+	case TokenSubstitution:
+		return "substitution"
+	default:
+		return "unknown token"
+	}
+}
+
+// Token is a slice of the source text. Text holds the exact bytes, so quoted
+// strings keep their quotes and escapes and comments keep their markers.
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Offset int
+}
+
+// CSTKind classifies the nodes of a concrete syntax tree.
+type CSTKind int
+
+const (
+	// CSTDocument is the root. Its children are trivia tokens around at most one object.
+	CSTDocument CSTKind = iota
+	// CSTObject holds fields, includes, commas and trivia, plus its braces when present.
+	CSTObject
+	// CSTArray holds values, commas and trivia between its brackets.
+	CSTArray
+	// CSTField holds a key, an optional separator and a value, with the whitespace between them.
+	CSTField
+	// CSTInclude holds the include keyword followed by the tokens of its target.
+	CSTInclude
+	// CSTKey holds the tokens of a path expression, including inner whitespace.
+	CSTKey
+	// CSTValue holds the parts of a value; more than one part is a concatenation.
+	CSTValue
+	// CSTToken is a leaf wrapping a single token.
+	CSTToken
+)
+
+// CSTNode is a node of the lossless concrete syntax tree produced by ParseCST.
+// Concatenating the tokens of all leaves reproduces the input byte for byte.
+type CSTNode struct {
+	Kind     CSTKind
+	Token    *Token
+	Children []*CSTNode
+}
+
+// Text returns the source text covered by the node.
+func (n *CSTNode) Text() string {
+	var b strings.Builder
+	n.writeText(&b)
+	return b.String()
+}
+
+func (n *CSTNode) writeText(b *strings.Builder) {
+	if n.Token != nil {
+		b.WriteString(n.Token.Text)
+		return
+	}
+	for _, child := range n.Children {
+		child.writeText(b)
+	}
+}
+
+// Is reports whether n is a leaf holding a token of the given kind.
+func (n *CSTNode) Is(kind TokenKind) bool {
+	return n.Kind == CSTToken && n.Token.Kind == kind
+}
+
+// Braced reports whether an object node is delimited by braces. Only the root
+// object of a document may omit them.
+func (n *CSTNode) Braced() bool {
+	return n.Kind == CSTObject && len(n.Children) > 0 && n.Children[0].Is(TokenLeftBrace)
+}
+
+// ParseCST parses the document into a lossless concrete syntax tree. Unlike
+// Parse it keeps whitespace, comments, commas and the original spelling of
+// keys and values, and it does not load included documents.
+func (p *Parser) ParseCST() (*CSTNode, error) {
+	start := p.reader.idx
+	tokens, err := p.lex()
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	p.reader.idx = start
+	b := &cstBuilder{parser: p, tokens: tokens}
+	doc, err := b.document()
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	return doc, nil
+}
+
+// lex splits the remaining input into tokens. On error the reader is left at
+// the offending byte.
+func (p *Parser) lex() ([]Token, error) {
+	var tokens []Token
+	r := p.reader
+	for r.idx < len(r.data) {
+		start := r.idx
+		kind, err := p.lexToken()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: string(r.data[start:r.idx]), Offset: start})
+	}
+	return tokens, nil
+}
+
+func (p *Parser) lexToken() (TokenKind, error) {
+	r := p.reader
+	ch := r.data[r.idx]
+	switch ch {
+	case '\n':
+		r.idx++
+		return TokenNewline, nil
+	case '\r':
+		if r.idx+1 < len(r.data) && r.data[r.idx+1] == '\n' {
+			r.idx += 2
+			return TokenNewline, nil
+		}
+	case '#':
+		p.skipCommentText()
+		return TokenComment, nil
+	case '/':
+		if r.idx+1 < len(r.data) && r.data[r.idx+1] == '/' {
+			p.skipCommentText()
+			return TokenComment, nil
+		}
+	case '{':
+		r.idx++
+		return TokenLeftBrace, nil
+	case '}':
+		r.idx++
+		return TokenRightBrace, nil
+	case '[':
+		r.idx++
+		return TokenLeftBracket, nil
+	case ']':
+		r.idx++
+		return TokenRightBracket, nil
+	case ',':
+		r.idx++
+		return TokenComma, nil
+	case ':':
+		r.idx++
+		return TokenColon, nil
+	case '=':
+		r.idx++
+		return TokenEquals, nil
+	case '+':
+		if r.idx+1 < len(r.data) && r.data[r.idx+1] == '=' {
+			r.idx += 2
+			return TokenPlusEquals, nil
+		}
+		return 0, &unexpectedTokenError{Expected: "'+='", Found: ch}
+	case '"':
+		if bytes.HasPrefix(r.remaining(), []byte(`"""`)) {
+			return TokenMultilineString, p.skipMultilineString()
+		}
+		return TokenQuotedString, p.skipQuotedString()
+	case '$':
+		return TokenSubstitution, p.skipSubstitution()
+	}
+	if p.skipWhitespaceRun() {
+		return TokenWhitespace, nil
+	}
+	if ch < utf8.RuneSelf && ForbiddenTable[ch] {
+		return 0, &unexpectedTokenError{Expected: "a value or key", Found: ch}
+	}
+	return TokenUnquoted, p.skipUnquotedText()
+}
+
+// skipCommentText moves past a comment up to, but excluding, the line break.
+func (p *Parser) skipCommentText() {
+	r := p.reader
+	for r.idx < len(r.data) && r.data[r.idx] != '\n' {
+		if r.data[r.idx] == '\r' && r.idx+1 < len(r.data) && r.data[r.idx+1] == '\n' {
+			return
+		}
+		r.idx++
+	}
+}
+
+func (p *Parser) skipWhitespaceRun() bool {
+	r := p.reader
+	start := r.idx
+	for r.idx < len(r.data) {
+		if r.data[r.idx] == '\r' && r.idx+1 < len(r.data) && r.data[r.idx+1] == '\n' {
+			break
+		}
+		rn, size := utf8.DecodeRune(r.data[r.idx:])
+		if !isHorizontalWhitespace(rn) && rn != '\r' {
+			break
+		}
+		r.idx += size
+	}
+	return r.idx > start
+}
+
+func (p *Parser) skipQuotedString() error {
+	r := p.reader
+	r.idx++
+	for r.idx < len(r.data) {
+		switch r.data[r.idx] {
+		case '"':
+			r.idx++
+			return nil
+		case '\\':
+			r.idx += 2
+			continue
+		case '\n':
+			return &unexpectedTokenError{Expected: "'\"'", Found: '\n'}
+		}
+		r.idx++
+	}
+	r.idx = len(r.data)
+	return errEOF
+}
+
+// skipMultilineString moves past a triple-quoted string. As in HOCON, quotes
+// directly before the closing delimiter belong to the string.
+func (p *Parser) skipMultilineString() error {
+	r := p.reader
+	end := strings.Index(string(r.data[r.idx+3:]), `"""`)
+	if end < 0 {
+		r.idx = len(r.data)
+		return errEOF
+	}
+	r.idx += 3 + end + 3
+	for r.idx < len(r.data) && r.data[r.idx] == '"' {
+		r.idx++
+	}
+	return nil
+}
+
+func (p *Parser) skipSubstitution() error {
+	r := p.reader
+	if r.idx+1 >= len(r.data) || r.data[r.idx+1] != '{' {
+		r.idx++
+		if r.idx >= len(r.data) {
+			return errEOF
+		}
+		return &unexpectedTokenError{Expected: "'{'", Found: r.data[r.idx]}
+	}
+	r.idx += 2
+	for r.idx < len(r.data) {
+		switch r.data[r.idx] {
+		case '}':
+			r.idx++
+			return nil
+		case '"':
+			if err := p.skipQuotedString(); err != nil {
+				return err
+			}
+			continue
+		case '\n':
+			return &unexpectedTokenError{Expected: "'}'", Found: '\n'}
+		}
+		r.idx++
+	}
+	return errEOF
+}
+
+func (p *Parser) skipUnquotedText() error {
+	r := p.reader
+	for r.idx < len(r.data) {
+		ch := r.data[r.idx]
+		if ch < utf8.RuneSelf {
+			if ForbiddenTable[ch] || ch == '\n' || ch == '\r' {
+				return nil
+			}
+			if ch == '/' && r.idx+1 < len(r.data) && r.data[r.idx+1] == '/' {
+				return nil
+			}
+		}
+		rn, size := utf8.DecodeRune(r.data[r.idx:])
+		if rn == utf8.RuneError && size == 1 {
+			return errors.New("invalid utf-8")
+		}
+		if isWhitespace(rn) {
+			return nil
+		}
+		r.idx += size
+	}
+	return nil
+}
+
+// cstBuilder arranges tokens into a tree, checking the structure of the
+// document as it goes.
+type cstBuilder struct {
+	parser *Parser
+	tokens []Token
+	pos    int
+}
+
+func (b *cstBuilder) peek() *Token {
+	if b.pos >= len(b.tokens) {
+		return nil
+	}
+	return &b.tokens[b.pos]
+}
+
+func (b *cstBuilder) peekKind(kind TokenKind) bool {
+	tok := b.peek()
+	return tok != nil && tok.Kind == kind
+}
+
+func (b *cstBuilder) take() *CSTNode {
+	tok := &b.tokens[b.pos]
+	b.pos++
+	return &CSTNode{Kind: CSTToken, Token: tok}
+}
+
+// fail positions the reader at the current token and returns an error
+// describing what was expected there.
+func (b *cstBuilder) fail(expected string) error {
+	tok := b.peek()
+	if tok == nil {
+		b.parser.reader.idx = len(b.parser.reader.data)
+		return &unexpectedTokenError{Expected: expected}
+	}
+	b.parser.reader.idx = tok.Offset
+	return &unexpectedTokenError{Expected: expected, Found: tok.Text[0]}
+}
+
+// trivia appends whitespace and comments, and newlines when allowed. It
+// reports whether a newline was consumed.
+func (b *cstBuilder) trivia(node *CSTNode, newlines bool) bool {
+	sawNewline := false
+	for tok := b.peek(); tok != nil; tok = b.peek() {
+		switch {
+		case tok.Kind == TokenWhitespace, tok.Kind == TokenComment:
+		case tok.Kind == TokenNewline && newlines:
+			sawNewline = true
+		default:
+			return sawNewline
+		}
+		node.Children = append(node.Children, b.take())
+	}
+	return sawNewline
+}
+
+// blank appends whitespace and newlines, which may separate a key from its
+// separator and value, as they do for Parse.
+func (b *cstBuilder) blank(node *CSTNode) {
+	for b.peekKind(TokenWhitespace) || b.peekKind(TokenNewline) {
+		node.Children = append(node.Children, b.take())
+	}
+}
+
+func (b *cstBuilder) document() (*CSTNode, error) {
+	doc := &CSTNode{Kind: CSTDocument}
+	b.trivia(doc, true)
+	tok := b.peek()
+	if tok == nil {
+		return doc, nil
+	}
+	obj := &CSTNode{Kind: CSTObject}
+	braced := tok.Kind == TokenLeftBrace
+	if braced {
+		obj.Children = append(obj.Children, b.take())
+	}
+	if err := b.objectBody(obj, braced); err != nil {
+		return nil, err
+	}
+	doc.Children = append(doc.Children, obj)
+	b.trivia(doc, true)
+	if b.peek() != nil {
+		return nil, b.fail("end of input")
+	}
+	return doc, nil
+}
+
+func (b *cstBuilder) object() (*CSTNode, error) {
+	if err := b.parser.increaseDepth(); err != nil {
+		return nil, err
+	}
+	defer b.parser.decreaseDepth()
+	obj := &CSTNode{Kind: CSTObject, Children: []*CSTNode{b.take()}}
+	return obj, b.objectBody(obj, true)
+}
+
+func (b *cstBuilder) objectBody(obj *CSTNode, braced bool) error {
+	// separated is true when a new field may start: at the beginning of the
+	// object and after a newline or comma.
+	separated, afterComma := true, false
+	for {
+		b.trivia(obj, false)
+		tok := b.peek()
+		switch {
+		case tok == nil:
+			if braced {
+				return b.fail("'}'")
+			}
+			return nil
+		case tok.Kind == TokenRightBrace:
+			if !braced {
+				return b.fail("end of input")
+			}
+			obj.Children = append(obj.Children, b.take())
+			return nil
+		case tok.Kind == TokenNewline:
+			obj.Children = append(obj.Children, b.take())
+			separated = true
+		case tok.Kind == TokenComma:
+			if afterComma || len(fieldsOf(obj)) == 0 {
+				return b.fail("a field")
+			}
+			obj.Children = append(obj.Children, b.take())
+			separated, afterComma = true, true
+		case !separated:
+			return b.fail("newline or ','")
+		default:
+			field, err := b.field()
+			if err != nil {
+				return err
+			}
+			obj.Children = append(obj.Children, field)
+			separated, afterComma = false, false
+		}
+	}
+}
+
+func fieldsOf(obj *CSTNode) []*CSTNode {
+	var fields []*CSTNode
+	for _, child := range obj.Children {
+		if child.Kind == CSTField || child.Kind == CSTInclude {
+			fields = append(fields, child)
+		}
+	}
+	return fields
+}
+
+var includeTargetPrefixes = []string{"file(", "url(", "classpath(", "required("}
+
+// isInclude reports whether the tokens at the current position start an
+// include statement rather than a key named include.
+func (b *cstBuilder) isInclude() bool {
+	if b.pos+2 >= len(b.tokens) {
+		return false
+	}
+	keyword, space, target := b.tokens[b.pos], b.tokens[b.pos+1], b.tokens[b.pos+2]
+	if keyword.Kind != TokenUnquoted || keyword.Text != "include" || space.Kind != TokenWhitespace {
+		return false
+	}
+	if target.Kind == TokenQuotedString {
+		return true
+	}
+	if target.Kind != TokenUnquoted {
+		return false
+	}
+	for _, prefix := range includeTargetPrefixes {
+		if strings.HasPrefix(target.Text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *cstBuilder) field() (*CSTNode, error) {
+	if b.isInclude() {
+		return b.include(), nil
+	}
+	field := &CSTNode{Kind: CSTField}
+	key := &CSTNode{Kind: CSTKey}
+	for tok := b.peek(); tok != nil; tok = b.peek() {
+		if tok.Kind != TokenUnquoted && tok.Kind != TokenQuotedString && tok.Kind != TokenWhitespace {
+			break
+		}
+		key.Children = append(key.Children, b.take())
+	}
+	b.pos -= trimTrailingWhitespace(key)
+	if len(key.Children) == 0 {
+		return nil, b.fail("a key")
+	}
+	field.Children = append(field.Children, key)
+	b.blank(field)
+	tok := b.peek()
+	switch {
+	case tok == nil:
+		return nil, b.fail("':' or '='")
+	case tok.Kind == TokenColon, tok.Kind == TokenEquals, tok.Kind == TokenPlusEquals:
+		field.Children = append(field.Children, b.take())
+		b.blank(field)
+	case tok.Kind != TokenLeftBrace:
+		return nil, b.fail("':' or '='")
+	}
+	value, err := b.value()
+	if err != nil {
+		return nil, err
+	}
+	field.Children = append(field.Children, value)
+	return field, nil
+}
+
+// include collects the keyword and the target tokens up to the end of the statement.
+func (b *cstBuilder) include() *CSTNode {
+	node := &CSTNode{Kind: CSTInclude}
+	for tok := b.peek(); tok != nil; tok = b.peek() {
+		switch tok.Kind {
+		case TokenUnquoted, TokenQuotedString, TokenWhitespace:
+			node.Children = append(node.Children, b.take())
+			continue
+		}
+		break
+	}
+	b.pos -= trimTrailingWhitespace(node)
+	return node
+}
+
+func (b *cstBuilder) value() (*CSTNode, error) {
+	value := &CSTNode{Kind: CSTValue}
+	for tok := b.peek(); tok != nil; tok = b.peek() {
+		var part *CSTNode
+		switch tok.Kind {
+		case TokenLeftBrace:
+			obj, err := b.object()
+			if err != nil {
+				return nil, err
+			}
+			part = obj
+		case TokenLeftBracket:
+			arr, err := b.array()
+			if err != nil {
+				return nil, err
+			}
+			part = arr
+		case TokenQuotedString, TokenMultilineString, TokenUnquoted, TokenSubstitution, TokenWhitespace:
+			part = b.take()
+		}
+		if part == nil {
+			break
+		}
+		value.Children = append(value.Children, part)
+	}
+	b.pos -= trimTrailingWhitespace(value)
+	if len(value.Children) == 0 {
+		return nil, b.fail("a value")
+	}
+	return value, nil
+}
+
+func (b *cstBuilder) array() (*CSTNode, error) {
+	if err := b.parser.increaseDepth(); err != nil {
+		return nil, err
+	}
+	defer b.parser.decreaseDepth()
+	arr := &CSTNode{Kind: CSTArray, Children: []*CSTNode{b.take()}}
+	separated, afterComma, empty := true, false, true
+	for {
+		if b.trivia(arr, true) {
+			separated = true
+		}
+		tok := b.peek()
+		switch {
+		case tok == nil:
+			return nil, b.fail("']'")
+		case tok.Kind == TokenRightBracket:
+			arr.Children = append(arr.Children, b.take())
+			return arr, nil
+		case tok.Kind == TokenComma:
+			if afterComma || empty {
+				return nil, b.fail("a value")
+			}
+			arr.Children = append(arr.Children, b.take())
+			separated, afterComma = true, true
+		case !separated:
+			return nil, b.fail("',' or ']'")
+		default:
+			value, err := b.value()
+			if err != nil {
+				return nil, err
+			}
+			arr.Children = append(arr.Children, value)
+			separated, afterComma, empty = false, false, false
+		}
+	}
+}
+
+// trimTrailingWhitespace removes whitespace leaves from the end of node and
+// returns how many were removed so the caller can hand them back.
+func trimTrailingWhitespace(node *CSTNode) int {
+	n := 0
+	for len(node.Children) > 0 && node.Children[len(node.Children)-1].Is(TokenWhitespace) {
+		node.Children = node.Children[:len(node.Children)-1]
+		n++
+	}
+	return n
+}
+
+func (n *CSTNode) String() string {
+	if n.Token != nil {
+		return fmt.Sprintf("%s %q", n.Token.Kind, n.Token.Text)
+	}
+	return fmt.Sprintf("%d nodes %q", len(n.Children), n.Text())
+}
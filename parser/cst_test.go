@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCSTRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "resources", "*.conf"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	inputs := map[string]string{
+		"crlf":      "a = 1\r\nb {\r\n  c = [1,\r\n 2] // x\r\n}\r\n",
+		"concat":    "a = ${x} foo  \"bar\" ${?y}  \n",
+		"braced":    "  {\n a : 1, b : { c = [ ] }\n}  # end",
+		"multiline": "a = \"\"\"x\n\"y\"\"\"\"\n",
+		"include":   "include required(file(\"a.conf\"))\ninclude = 1\n",
+		"empty":     "",
+		"comments":  "# only\n// comments\n",
+		"unicode":   "\ufeffkey = 你好 世界\n",
+		"split":     "a =\n  1\n",
+		"colon":     "a:\n  \"x\"\n",
+		"brace":     "a\n{ b = 1 }\n",
+	}
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case "max_depth.conf", "object1.conf", "object4.conf":
+			// Deliberately malformed documents.
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		inputs[filepath.Base(file)] = string(data)
+	}
+	for name, input := range inputs {
+		doc, err := newTestParser(input).ParseCST()
+		if err != nil {
+			t.Fatalf("%s: ParseCST: %v", name, err)
+		}
+		if text := doc.Text(); text != input {
+			t.Fatalf("%s: round trip mismatch\ninput:  %q\noutput: %q", name, input, text)
+		}
+	}
+}
+
+func TestParseCSTStructure(t *testing.T) {
+	doc, err := newTestParser("a.\"b c\" = 1 2 // note\ninclude \"x.conf\"\n").ParseCST()
+	if err != nil {
+		t.Fatalf("ParseCST: %v", err)
+	}
+	root := doc.Children[0]
+	if root.Kind != CSTObject || root.Braced() {
+		t.Fatalf("expected braces-omitted root object, got %v", root)
+	}
+	fields := fieldsOf(root)
+	if len(fields) != 2 || fields[0].Kind != CSTField || fields[1].Kind != CSTInclude {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	key := fields[0].Children[0]
+	if key.Kind != CSTKey || key.Text() != `a."b c"` {
+		t.Fatalf("unexpected key %v", key)
+	}
+	value := fields[0].Children[len(fields[0].Children)-1]
+	if value.Kind != CSTValue || value.Text() != "1 2" {
+		t.Fatalf("unexpected value %v", value)
+	}
+}
+
+func TestParseCSTErrors(t *testing.T) {
+	cases := []struct {
+		input    string
+		line     int
+		column   int
+		expected string
+	}{
+		{"a = 1 b = 2", 1, 9, "newline or ','"},
+		{"a {\n  b = 1\n", 3, 1, "'}'"},
+		{"a = [1, 2", 1, 10, "']'"},
+		{"a = 1,,", 1, 7, "a field"},
+		{"a\nb = 1", 2, 1, "':' or '='"},
+		{"a = \"open", 1, 10, ""},
+		{"a = 1 }", 1, 7, "end of input"},
+	}
+	for _, tc := range cases {
+		_, err := newTestParser(tc.input).ParseCST()
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("%q: expected a SyntaxError, got %v", tc.input, err)
+		}
+		if syntaxErr.Line != tc.line || syntaxErr.Column != tc.column || syntaxErr.Expected != tc.expected {
+			t.Fatalf("%q: unexpected error %v (expected %q)", tc.input, err, syntaxErr.Expected)
+		}
+	}
+}
@@ -1,9 +1,153 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"hocon-go/common"
+	"io"
+	"strconv"
+	"strings"
 )
 
+// SyntaxError reports malformed input together with its position.
+type SyntaxError struct {
+	// File is the document the error was found in; empty for in-memory input.
+	File string
+	// Includes lists the files that included File, outermost first.
+	Includes []string
+	// Line and Column are 1-based; Column counts bytes.
+	Line   int
+	Column int
+	Offset int
+	// Expected and Found describe the offending token. Expected is empty when
+	// the error is not a token mismatch, in which case Msg describes it.
+	Expected string
+	Found    string
+	Msg      string
+	// Snippet is the offending source line with a caret under the error position.
+	Snippet string
+	Err     error
+}
+
+// newSyntaxError reports err at origin.
+func newSyntaxError(origin *common.Origin, err error) *SyntaxError {
+	return &SyntaxError{
+		File:     origin.File,
+		Includes: origin.Includes,
+		Line:     origin.Line,
+		Column:   origin.Column,
+		Offset:   origin.Offset,
+		Msg:      err.Error(),
+		Err:      err,
+	}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Origin().String(), e.message())
+}
+
+// message describes the error without its position.
+func (e *SyntaxError) message() string {
+	switch {
+	case e.Expected != "":
+		return fmt.Sprintf("expected %s, found %s", e.Expected, e.Found)
+	case e.Msg != "":
+		return e.Msg
+	default:
+		return "unexpected " + e.Found
+	}
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Origin returns the position of the error.
+func (e *SyntaxError) Origin() *common.Origin {
+	return &common.Origin{File: e.File, Includes: e.Includes, Line: e.Line, Column: e.Column, Offset: e.Offset}
+}
+
+func (*SyntaxError) Category() common.Category {
+	return common.CategoryParse
+}
+
+// Verbose renders the error followed by the source snippet.
+func (e *SyntaxError) Verbose() string {
+	if e.Snippet == "" {
+		return e.Error()
+	}
+	return e.Error() + "\n" + e.Snippet
+}
+
+// wrapError turns an error raised while parsing into a *SyntaxError positioned
+// at the current reader offset. Errors that already belong to the error
+// taxonomy, such as those from included documents, carry their own position
+// and are returned unchanged.
+func (p *Parser) wrapError(err error) error {
+	var categorized common.Error
+	if errors.As(err, &categorized) {
+		return err
+	}
+	return p.errorAt(p.reader.idx, err)
+}
+
+// errorAt reports err as a *SyntaxError at offset in the current document.
+func (p *Parser) errorAt(offset int, err error) *SyntaxError {
+	if offset > len(p.reader.data) {
+		offset = len(p.reader.data)
+	}
+	result := newSyntaxError(p.originAt(offset), err)
+	result.Msg = ""
+	result.Snippet = p.snippet(offset)
+	var tokenErr *unexpectedTokenError
+	switch {
+	case errors.As(err, &tokenErr):
+		result.Expected = tokenErr.Expected
+		result.Found = describeByte(tokenErr.Found, offset >= len(p.reader.data))
+	case errors.Is(err, io.EOF):
+		result.Found = "end of input"
+	default:
+		result.Msg = err.Error()
+	}
+	return result
+}
+
+// snippet renders the line containing offset with a caret below the offending byte.
+func (p *Parser) snippet(offset int) string {
+	data := p.reader.data
+	line, column := p.reader.position(offset)
+	start := offset - (column - 1)
+	end := start
+	for end < len(data) && data[end] != '\n' && data[end] != '\r' {
+		end++
+	}
+	text := string(data[start:end])
+	gutter := strconv.Itoa(line)
+	var caret strings.Builder
+	for i := 0; i < column-1 && i < len(text); i++ {
+		if text[i] == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+	return fmt.Sprintf("%s | %s\n%s | %s", gutter, text, strings.Repeat(" ", len(gutter)), caret.String())
+}
+
+func describeByte(ch byte, eof bool) string {
+	if eof {
+		return "end of input"
+	}
+	switch ch {
+	case '\n':
+		return "newline"
+	case '\r':
+		return "carriage return"
+	}
+	return strconv.QuoteRune(rune(ch))
+}
+
 type unexpectedTokenError struct {
 	Expected string
 	Found    byte
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	cases := []struct {
+		input    string
+		line     int
+		column   int
+		expected string
+		found    string
+		snippet  string
+	}{
+		{"a = 1\nb = ,\n", 2, 5, "value", "','", "2 | b = ,\n  |     ^"},
+		{"a = 1\n}", 2, 1, "end of input", "'}'", "2 | }\n  | ^"},
+		{"a {\n\tb = {c}\n}", 2, 8, ": or =", "'}'", "2 | \tb = {c}\n  | \t      ^"},
+		{"a = [1, 2", 1, 10, "", "end of input", "1 | a = [1, 2\n  |          ^"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := newTestParser(tc.input).Parse()
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != tc.line || syntaxErr.Column != tc.column {
+				t.Fatalf("expected %d:%d, got %d:%d (%v)", tc.line, tc.column, syntaxErr.Line, syntaxErr.Column, err)
+			}
+			if syntaxErr.Expected != tc.expected || syntaxErr.Found != tc.found {
+				t.Fatalf("expected %q/%q, got %q/%q", tc.expected, tc.found, syntaxErr.Expected, syntaxErr.Found)
+			}
+			if syntaxErr.Snippet != tc.snippet {
+				t.Fatalf("unexpected snippet:\n%s\nexpected:\n%s", syntaxErr.Snippet, tc.snippet)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorInInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		return path
+	}
+	broken := write("broken.conf", "x = {\n  y = ]\n}\n")
+	middle := write("middle.conf", `include "broken.conf"`)
+	main := write("main.conf", "a = 1\ninclude \"middle.conf\"\n")
+
+	_, err := ParseFile(main, DefaultConfigOptions())
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected SyntaxError, got %v", err)
+	}
+	if syntaxErr.File != broken || syntaxErr.Line != 2 || syntaxErr.Column != 7 {
+		t.Fatalf("unexpected position: %v", err)
+	}
+	if len(syntaxErr.Includes) != 2 || syntaxErr.Includes[0] != main || syntaxErr.Includes[1] != middle {
+		t.Fatalf("unexpected include stack %v", syntaxErr.Includes)
+	}
+	if !strings.Contains(err.Error(), "included from "+middle+" <- "+main) {
+		t.Fatalf("include stack missing from message: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+	"hocon-go/raw"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFetchTimeout     = 10 * time.Second
+	defaultFetchMaxBodySize = 10 << 20
+)
+
+// Document is a configuration document retrieved by a Fetcher.
+type Document struct {
+	// URL is the address the document was finally read from, after any
+	// redirects. Relative includes in the document are resolved against it.
+	URL string
+	// ContentType is the media type reported for the document, if any. It
+	// selects the syntax the document is parsed with.
+	ContentType string
+	Data        []byte
+}
+
+// Fetcher retrieves the documents named by url() includes and config.ParseURL.
+// A document that does not exist is reported with an error wrapping
+// fs.ErrNotExist, so that optional includes of it are skipped.
+type Fetcher interface {
+	Fetch(url string) (*Document, error)
+}
+
+// HTTPFetcher fetches documents over HTTP and HTTPS with net/http.
+type HTTPFetcher struct {
+	// Client sends the requests. When nil, a client with Timeout is used.
+	Client *http.Client
+	// Timeout bounds each request made without an explicit Client.
+	Timeout time.Duration
+	// MaxBodySize is the largest document accepted, in bytes.
+	MaxBodySize int64
+}
+
+// NewHTTPFetcher returns the fetcher used when ConfigOptions.Fetcher is nil:
+// requests time out after 10 seconds and documents are limited to 10 MiB.
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{Timeout: defaultFetchTimeout, MaxBodySize: defaultFetchMaxBodySize}
+}
+
+func (f *HTTPFetcher) Fetch(rawURL string) (*Document, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: f.Timeout}
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, fmt.Errorf("failed to fetch %s: %s: %w", rawURL, resp.Status, fs.ErrNotExist)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	limit := f.MaxBodySize
+	if limit <= 0 {
+		limit = defaultFetchMaxBodySize
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("failed to fetch %s: document is larger than %d bytes", rawURL, limit)
+	}
+	return &Document{
+		URL:         resp.Request.URL.String(),
+		ContentType: resp.Header.Get("Content-Type"),
+		Data:        data,
+	}, nil
+}
+
+func (o ConfigOptions) fetcher() Fetcher {
+	if o.Fetcher != nil {
+		return o.Fetcher
+	}
+	return NewHTTPFetcher()
+}
+
+// documentSyntax chooses the syntax of a fetched document from its content
+// type. Generic types fall back to the extension of its URL, and then to
+// fallback.
+func documentSyntax(doc *Document, fallback fileSyntax) (fileSyntax, error) {
+	mediaType := ""
+	if doc.ContentType != "" {
+		parsed, _, err := mime.ParseMediaType(doc.ContentType)
+		if err != nil {
+			return 0, fmt.Errorf("invalid content type %q for %s", doc.ContentType, doc.URL)
+		}
+		mediaType = parsed
+	}
+	switch mediaType {
+	case "application/hocon":
+		return syntaxHocon, nil
+	case "application/json":
+		return syntaxJSON, nil
+	case "text/x-java-properties":
+		return syntaxProperties, nil
+	case "", "text/plain", "application/octet-stream":
+		if u, err := url.Parse(doc.URL); err == nil {
+			switch strings.ToLower(path.Ext(u.Path)) {
+			case ".conf", ".hocon":
+				return syntaxHocon, nil
+			case ".json":
+				return syntaxJSON, nil
+			case ".properties":
+				return syntaxProperties, nil
+			}
+		}
+		return fallback, nil
+	default:
+		return 0, fmt.Errorf("unsupported content type %q for %s", mediaType, doc.URL)
+	}
+}
+
+// ParseURL fetches the document at rawURL with the configured Fetcher and
+// parses it, loading its includes.
+func ParseURL(rawURL string, opts ConfigOptions) (*raw.Object, error) {
+	opts = normalizeOptions(opts)
+	loader := includeLoader{parser: newParser(nil, opts, "", includeContext{})}
+	return loader.fetch(rawURL, syntaxHocon)
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newConfigServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	docs := map[string]struct{ contentType, body string }{
+		"/conf/app.conf": {"", `
+include "common"
+include url("db.json")
+include "optional-missing"
+name = app
+`},
+		"/conf/common.conf":   {"text/plain; charset=utf-8", `common = true`},
+		"/conf/db.json":       {"application/json", `{"db": {"port": 5432}}`},
+		"/conf/typed":         {"application/hocon", `typed = yes`},
+		"/conf/page":          {"text/html", `<html></html>`},
+		"/conf/big.conf":      {"", "big = \"" + strings.Repeat("x", 64) + "\""},
+		"/conf/required.conf": {"", `include required(url("gone.conf"))`},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if doc.contentType != "" {
+			w.Header().Set("Content-Type", doc.contentType)
+		} else {
+			// Keep net/http from sniffing a content type.
+			w.Header()["Content-Type"] = nil
+		}
+		w.Write([]byte(doc.body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseURLIncludes(t *testing.T) {
+	server := newConfigServer(t)
+	obj, err := ParseURL(server.URL+"/conf/app.conf", DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("ParseURL: %v", err)
+	}
+	for path, expected := range map[string]string{
+		"common":  "true",
+		"db.port": "5432",
+		"name":    "app",
+	} {
+		if value := valueAt(t, obj, path); value != expected {
+			t.Errorf("%s = %v, expected %s", path, value, expected)
+		}
+	}
+}
+
+func TestParseURLContentTypes(t *testing.T) {
+	server := newConfigServer(t)
+	if _, err := ParseURL(server.URL+"/conf/typed", DefaultConfigOptions()); err != nil {
+		t.Fatalf("ParseURL(typed): %v", err)
+	}
+	_, err := ParseURL(server.URL+"/conf/page", DefaultConfigOptions())
+	if err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Fatalf("expected content type error, got %v", err)
+	}
+}
+
+func TestParseURLErrors(t *testing.T) {
+	server := newConfigServer(t)
+	opts := DefaultConfigOptions()
+	opts.Fetcher = &HTTPFetcher{MaxBodySize: 32}
+	if _, err := ParseURL(server.URL+"/conf/big.conf", opts); err == nil || !strings.Contains(err.Error(), "larger than 32 bytes") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+	if _, err := ParseURL(server.URL+"/conf/none.conf", DefaultConfigOptions()); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	_, err := ParseURL(server.URL+"/conf/required.conf", DefaultConfigOptions())
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "gone.conf") {
+		t.Fatalf("expected missing required include, got %v", err)
+	}
+}
+
+type mapFetcher map[string]string
+
+func (m mapFetcher) Fetch(url string) (*Document, error) {
+	body, ok := m[url]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return &Document{URL: url, Data: []byte(body)}, nil
+}
+
+func TestCustomFetcher(t *testing.T) {
+	opts := DefaultConfigOptions()
+	opts.Fetcher = mapFetcher{
+		"mem://configs/a.conf": `a = 1, include url("b.conf")`,
+		"mem://configs/b.conf": `b = 2, include url("a.conf")`,
+	}
+	_, err := NewParser([]byte(`include url("mem://configs/a.conf")`)).WithOptions(opts).Parse()
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/main.conf":       {Data: []byte("include \"db\"\ninclude classpath(\"shared/log\")\ninclude \"missing\"\nname = main")},
+		"app/db.json":         {Data: []byte(`{"db": {"port": 5432}}`)},
+		"shared/log.conf":     {Data: []byte(`log.level = info, include "format"`)},
+		"shared/format.conf":  {Data: []byte(`log.format = json`)},
+		"app/escape/bad.conf": {Data: []byte(`include "../../../../etc/passwd"`)},
+	}
+	opts := DefaultConfigOptions()
+	opts.ClasspathFS = []fs.FS{fsys}
+
+	obj, err := ParseFS(fsys, "app/main", opts)
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	for path, expected := range map[string]string{
+		"name":       "main",
+		"db.port":    "5432",
+		"log.level":  "info",
+		"log.format": "json",
+	} {
+		if value := valueAt(t, obj, path); value != expected {
+			t.Errorf("%s = %q, expected %q", path, value, expected)
+		}
+	}
+
+	if _, err := ParseFS(fsys, "app/escape/bad.conf", opts); err != nil {
+		t.Fatalf("paths leaving the file system should be skipped: %v", err)
+	}
+	if _, err := ParseFS(fsys, "app/none", opts); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestPlainIncludeFallsBackToClasspath(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.conf")
+	if err := os.WriteFile(main, []byte(`include "reference/ref", include "local"`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "local.conf"), []byte(`local = disk`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	opts := DefaultConfigOptions()
+	opts.ClasspathFS = []fs.FS{fstest.MapFS{
+		"reference/ref.conf": {Data: []byte(`ref = embedded`)},
+		"local.conf":         {Data: []byte(`local = embedded`)},
+	}}
+	obj, err := ParseFile(main, opts)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if ref := valueAt(t, obj, "ref"); ref != "embedded" {
+		t.Fatalf("ref = %q", ref)
+	}
+	if local := valueAt(t, obj, "local"); local != "disk" {
+		t.Fatalf("local = %q, the file next to main.conf should win", local)
+	}
+}
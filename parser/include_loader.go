@@ -1,15 +1,18 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hocon-go/common"
 	"hocon-go/raw"
 	"io"
-	"math"
+	"io/fs"
+	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
@@ -29,38 +32,84 @@ func (ctx includeContext) push(path string) (includeContext, error) {
 	return includeContext{chain: newChain}, nil
 }
 
+// ParseFile parses the file at path and loads its includes. Files with the
+// .properties extension are read as Java properties, anything else as HOCON.
 func ParseFile(path string, opts ConfigOptions) (*raw.Object, error) {
-	opts = normalizeOptions(opts)
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
 	}
 	data, err := os.ReadFile(abs)
 	if err != nil {
-		return nil, err
+		return nil, &common.IOError{Resource: path, Err: err}
 	}
-	ctx := includeContext{}
-	ctx, err = ctx.push(abs)
-	if err != nil {
-		return nil, err
+	if strings.EqualFold(filepath.Ext(abs), ".properties") {
+		return ParseProperties(data, abs)
 	}
-	parser := newParser(data, opts, filepath.Dir(abs), ctx)
-	return parser.Parse()
+	return NewFileParser(abs, data, opts).Parse()
+}
+
+// NewFileParser returns a parser for data as the HOCON contents of the file
+// at path, such as an editor buffer that has not been saved. Its includes are
+// looked up relative to the file and the origins of its values name it.
+func NewFileParser(path string, data []byte, opts ConfigOptions) *Parser {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	ctx, _ := includeContext{}.push(path)
+	return newParser(data, normalizeOptions(opts), filepath.Dir(path), ctx)
 }
 
-func (p *Parser) parseInclusion(inclusion *raw.Inclusion) error {
+func (p *Parser) parseInclusion(inclusion *raw.Inclusion, start int) error {
 	loader := includeLoader{parser: p}
 	obj, err := loader.load(inclusion)
 	if err != nil {
-		if inclusion.Required || !errors.Is(err, os.ErrNotExist) {
-			return err
-		}
-		return nil
+		return p.includeFailure(inclusion, start, err)
 	}
 	inclusion.Val = obj
 	return nil
 }
 
+// includeFailure reports an include that could not be loaded, positioned at
+// the include statement at start. A missing optional include is skipped with
+// a nil error. Errors from within the included document are already
+// positioned and are returned as they are.
+func (p *Parser) includeFailure(inclusion *raw.Inclusion, start int, err error) error {
+	origin := p.originAt(start)
+	fetchErr, fetchFailed := err.(*common.IOError)
+	var categorized common.Error
+	var pathErr *fs.PathError
+	switch {
+	case fetchFailed && fetchErr.Origin == nil:
+		// The document itself could not be fetched.
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		fetchErr.Origin = origin
+		return fetchErr
+	case errors.As(err, &categorized):
+		return err
+	case errors.As(err, &pathErr):
+	case !errors.Is(err, fs.ErrNotExist):
+		return p.errorAt(start, fmt.Errorf("%s: %w", inclusion.String(), err))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		if !inclusion.Required {
+			return nil
+		}
+		return &common.IncludeNotFound{Include: inclusion.String(), Origin: origin, Err: err}
+	}
+	return &common.IOError{Resource: inclusion.Path, Origin: origin, Err: err}
+}
+
+// documentMissing reports whether err means that the document being loaded
+// does not exist, rather than that something within it, such as a required
+// include, is missing.
+func documentMissing(err error) bool {
+	var notFound *common.IncludeNotFound
+	return errors.Is(err, fs.ErrNotExist) && !errors.As(err, &notFound)
+}
+
 type includeLoader struct {
 	parser *Parser
 }
@@ -72,8 +121,12 @@ func (l includeLoader) load(inclusion *raw.Inclusion) (*raw.Object, error) {
 	}
 	location := inclusion.Location
 	switch {
+	case location == nil && l.parser.baseURL != "":
+		return l.loadFromRelativeURL(path)
 	case location == nil:
-		return l.loadFromFile(path)
+		return l.loadRelative(path)
+	case *location == raw.Url:
+		return l.loadFromURL(path)
 	case *location == raw.File:
 		return l.loadFromFile(path)
 	case *location == raw.Classpath:
@@ -87,41 +140,173 @@ func (l includeLoader) loadFromClasspath(path string) (*raw.Object, error) {
 	if filepath.IsAbs(path) {
 		return nil, fmt.Errorf("classpath include %q must be relative", path)
 	}
-	if len(l.parser.options.Classpath) == 0 {
+	bases := l.classpathBases()
+	if len(bases) == 0 {
 		return nil, os.ErrNotExist
 	}
-	return l.loadFromBases(path, l.parser.options.Classpath)
+	return l.loadFromBases(path, bases)
+}
+
+// classpathBases returns the classpath directories followed by the classpath
+// file systems.
+func (l includeLoader) classpathBases() []includeBase {
+	opts := l.parser.options
+	bases := make([]includeBase, 0, len(opts.Classpath)+len(opts.ClasspathFS))
+	for _, dir := range opts.Classpath {
+		bases = append(bases, includeBase{dir: dir})
+	}
+	for _, fsys := range opts.ClasspathFS {
+		bases = append(bases, includeBase{fsys: fsys, dir: "."})
+	}
+	return bases
+}
+
+// loadRelative loads a plain include. It is looked up next to the including
+// document first and on the classpath second.
+func (l includeLoader) loadRelative(path string) (*raw.Object, error) {
+	if l.parser.fsys == nil {
+		obj, err := l.loadFromFile(path)
+		if err == nil || !documentMissing(err) || filepath.IsAbs(path) {
+			return obj, err
+		}
+		return l.loadFromBases(path, l.classpathBases())
+	}
+	bases := append([]includeBase{{fsys: l.parser.fsys, dir: l.parser.baseDir}}, l.classpathBases()...)
+	return l.loadFromBases(path, bases)
+}
+
+// loadFromURL fetches an url() include. Its address is used as is, without
+// trying extensions; a relative address is resolved against the URL of the
+// including document.
+func (l includeLoader) loadFromURL(path string) (*raw.Object, error) {
+	target, err := l.resolveURL(path)
+	if err != nil {
+		return nil, err
+	}
+	return l.fetch(target, syntaxHocon)
+}
+
+// loadFromRelativeURL loads a plain include found in a fetched document. The
+// name is resolved against the document's URL and tried with each extension.
+func (l includeLoader) loadFromRelativeURL(path string) (*raw.Object, error) {
+	for _, cand := range buildFileCandidates(path) {
+		target, err := l.resolveURL(cand.path)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := l.fetch(target, cand.syntax)
+		if err == nil {
+			return obj, nil
+		}
+		if !documentMissing(err) {
+			return nil, err
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (l includeLoader) resolveURL(path string) (string, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid include url %q: %w", path, err)
+	}
+	if l.parser.baseURL != "" {
+		base, err := url.Parse(l.parser.baseURL)
+		if err != nil {
+			return "", err
+		}
+		ref = base.ResolveReference(ref)
+	}
+	if !ref.IsAbs() {
+		return "", fmt.Errorf("include url %q must be absolute", path)
+	}
+	return ref.String(), nil
+}
+
+// fetch retrieves and parses the document at target. fallback is the syntax
+// used when neither the content type nor the URL tells it.
+func (l includeLoader) fetch(target string, fallback fileSyntax) (*raw.Object, error) {
+	childCtx, err := l.parser.ctx.push(target)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := l.parser.options.fetcher().Fetch(target)
+	if err != nil {
+		return nil, &common.IOError{Resource: target, Err: err}
+	}
+	syntax, err := documentSyntax(doc, fallback)
+	if err != nil {
+		return nil, &common.IOError{Resource: target, Err: err}
+	}
+	switch syntax {
+	case syntaxHocon:
+		parser := newParser(doc.Data, l.parser.options, "", childCtx)
+		parser.baseURL = doc.URL
+		return parser.Parse()
+	case syntaxJSON:
+		origin := &common.Origin{File: target, Includes: l.parser.ctx.chain}
+		return parseJSON(bytes.NewReader(doc.Data), origin)
+	case syntaxProperties:
+		return l.parseProperties(doc.Data, target)
+	default:
+		return nil, fmt.Errorf("unsupported include syntax for %s", target)
+	}
 }
 
 func (l includeLoader) loadFromFile(path string) (*raw.Object, error) {
-	var bases []string
+	var bases []includeBase
 	if filepath.IsAbs(path) {
-		bases = []string{""}
+		bases = []includeBase{{}}
 	} else {
-		if l.parser.baseDir != "" {
-			bases = append(bases, l.parser.baseDir)
+		if l.parser.baseDir != "" && l.parser.fsys == nil {
+			bases = append(bases, includeBase{dir: l.parser.baseDir})
 		}
-		bases = append(bases, "")
+		bases = append(bases, includeBase{})
 	}
 	return l.loadFromBases(path, bases)
 }
 
-func (l includeLoader) loadFromBases(path string, bases []string) (*raw.Object, error) {
+// includeBase is a directory includes are looked up in: a directory of the
+// operating system, or of fsys when it is set. The working directory is the
+// zero value.
+type includeBase struct {
+	fsys fs.FS
+	dir  string
+}
+
+func (l includeLoader) loadFromBases(path string, bases []includeBase) (*raw.Object, error) {
 	candidates := buildFileCandidates(path)
 	seen := map[string]struct{}{}
-	for _, base := range bases {
+	for i, base := range bases {
 		for _, cand := range candidates {
-			full := l.makeAbsolute(base, cand.path)
-			key := fmt.Sprintf("%s|%d", full, cand.syntax)
-			if _, ok := seen[key]; ok {
+			var key string
+			if base.fsys == nil {
+				key = l.makeAbsolute(base.dir, cand.path)
+			} else {
+				key = pathpkg.Join(base.dir, filepath.ToSlash(cand.path))
+				if !fs.ValidPath(key) {
+					continue
+				}
+			}
+			seenKey := fmt.Sprintf("%s|%d", key, cand.syntax)
+			if base.fsys != nil {
+				seenKey = fmt.Sprintf("fs%d|%s", i, seenKey)
+			}
+			if _, ok := seen[seenKey]; ok {
 				continue
 			}
-			seen[key] = struct{}{}
-			obj, err := l.openFile(full, cand.syntax)
+			seen[seenKey] = struct{}{}
+			var obj *raw.Object
+			var err error
+			if base.fsys == nil {
+				obj, err = l.openFile(key, cand.syntax)
+			} else {
+				obj, err = l.openFSFile(base.fsys, key, cand.syntax)
+			}
 			if err == nil {
 				return obj, nil
 			}
-			if !errors.Is(err, os.ErrNotExist) {
+			if !documentMissing(err) {
 				return nil, err
 			}
 		}
@@ -156,12 +341,26 @@ func (l includeLoader) openFile(path string, syntax fileSyntax) (*raw.Object, er
 	case syntaxHocon:
 		return l.parseHoconFile(path)
 	case syntaxJSON:
-		return parseJSONFile(path)
+		origin := &common.Origin{File: path, Includes: l.parser.ctx.chain}
+		return parseJSONFile(path, origin)
+	case syntaxProperties:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return l.parseProperties(data, path)
 	default:
 		return nil, fmt.Errorf("unsupported include syntax for %s", path)
 	}
 }
 
+// parseProperties reads an included properties document named name.
+func (l includeLoader) parseProperties(data []byte, name string) (*raw.Object, error) {
+	return parseProperties(data, func(line int) *common.Origin {
+		return &common.Origin{File: name, Includes: l.parser.ctx.chain, Line: line, Column: 1}
+	})
+}
+
 func (l includeLoader) parseHoconFile(path string) (*raw.Object, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -176,56 +375,146 @@ func (l includeLoader) parseHoconFile(path string) (*raw.Object, error) {
 	return parser.Parse()
 }
 
-func parseJSONFile(path string) (*raw.Object, error) {
+// openFSFile parses the file name in fsys. Its plain includes are looked up
+// in the same directory of fsys.
+func (l includeLoader) openFSFile(fsys fs.FS, name string, syntax fileSyntax) (*raw.Object, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, os.ErrNotExist
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	switch syntax {
+	case syntaxHocon:
+		childCtx, err := l.parser.ctx.push(name)
+		if err != nil {
+			return nil, err
+		}
+		parser := newParser(data, l.parser.options, pathpkg.Dir(name), childCtx)
+		parser.fsys = fsys
+		return parser.Parse()
+	case syntaxJSON:
+		origin := &common.Origin{File: name, Includes: l.parser.ctx.chain}
+		return parseJSON(bytes.NewReader(data), origin)
+	case syntaxProperties:
+		return l.parseProperties(data, name)
+	default:
+		return nil, fmt.Errorf("unsupported include syntax for %s", name)
+	}
+}
+
+// ParseFS parses the file name in fsys, trying the .conf, .json and
+// .properties extensions when name has none. Plain includes in it are looked up in fsys first.
+func ParseFS(fsys fs.FS, name string, opts ConfigOptions) (*raw.Object, error) {
+	opts = normalizeOptions(opts)
+	loader := includeLoader{parser: newParser(nil, opts, "", includeContext{})}
+	obj, err := loader.loadFromBases(name, []includeBase{{fsys: fsys, dir: "."}})
+	var categorized common.Error
+	switch {
+	case err == nil || errors.As(err, &categorized):
+		return obj, err
+	case documentMissing(err):
+		return nil, &common.IOError{Resource: name, Err: &fs.PathError{Op: "open", Path: name, Err: err}}
+	default:
+		return nil, &common.IOError{Resource: name, Err: err}
+	}
+}
+
+// parseJSONFile reads a JSON document. encoding/json does not report positions,
+// so every value is attributed to origin as a whole.
+func parseJSONFile(path string, origin *common.Origin) (*raw.Object, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	decoder := json.NewDecoder(file)
+	return parseJSON(file, origin)
+}
+
+// parseJSON reads a JSON document whose values are all attributed to origin.
+// The document is read token by token so that the fields keep the order they
+// are written in. Malformed documents are reported as a *SyntaxError at
+// origin.
+func parseJSON(r io.Reader, origin *common.Origin) (*raw.Object, error) {
+	decoder := json.NewDecoder(r)
 	decoder.UseNumber()
-	var data interface{}
-	if err := decoder.Decode(&data); err != nil && err != io.EOF {
-		return nil, err
+	rawValue, err := decodeJSONValue(decoder, origin)
+	if err == io.EOF {
+		rawValue, err = &raw.Null{}, nil
 	}
-	rawValue, err := jsonValueToRaw(data)
 	if err != nil {
-		return nil, err
+		return nil, newSyntaxError(origin, err)
 	}
 	obj, ok := rawValue.(*raw.Object)
 	if !ok {
-		return nil, fmt.Errorf("JSON root must be an object")
+		return nil, newSyntaxError(origin, errors.New("JSON root must be an object"))
 	}
 	return obj, nil
 }
 
-func jsonValueToRaw(v interface{}) (raw.Value, error) {
-	switch val := v.(type) {
-	case map[string]interface{}:
-		keys := make([]string, 0, len(val))
-		for k := range val {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		fields := make([]raw.ObjectField, 0, len(keys))
-		for _, k := range keys {
-			child, err := jsonValueToRaw(val[k])
-			if err != nil {
+// decodeJSONValue reads the next value from decoder.
+func decodeJSONValue(decoder *json.Decoder, origin *common.Origin) (raw.Value, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	result, err := convertJSONToken(decoder, token, origin)
+	if err != nil {
+		return nil, err
+	}
+	result.SetOrigin(origin)
+	return result, nil
+}
+
+// convertJSONToken converts the value starting with token, reading the rest
+// of arrays and objects from decoder.
+func convertJSONToken(decoder *json.Decoder, token json.Token, origin *common.Origin) (raw.Value, error) {
+	switch val := token.(type) {
+	case json.Delim:
+		switch val {
+		case '{':
+			var fields []raw.ObjectField
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyToken.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid JSON object key %v", keyToken)
+				}
+				child, err := decodeJSONValue(decoder, origin)
+				if err != nil {
+					return nil, err
+				}
+				field := &raw.KeyValueField{Key: raw.NewQuotedString(key), Value: child}
+				field.SetOrigin(origin)
+				fields = append(fields, field)
+			}
+			if _, err := decoder.Token(); err != nil {
 				return nil, err
 			}
-			fields = append(fields, raw.NewKeyValueField(raw.NewQuotedString(k), child))
-		}
-		return raw.NewObject(fields), nil
-	case []interface{}:
-		values := make([]raw.Value, len(val))
-		for i, item := range val {
-			child, err := jsonValueToRaw(item)
-			if err != nil {
+			return raw.NewObject(fields), nil
+		case '[':
+			values := []raw.Value{}
+			for decoder.More() {
+				child, err := decodeJSONValue(decoder, origin)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, child)
+			}
+			if _, err := decoder.Token(); err != nil {
 				return nil, err
 			}
-			values[i] = child
+			return raw.NewRawArray(values), nil
 		}
-		return raw.NewRawArray(values), nil
+		return nil, fmt.Errorf("unexpected JSON delimiter %v", val)
 	case json.Number:
 		if i64, err := val.Int64(); err == nil {
 			if i64 >= 0 {
@@ -237,22 +526,14 @@ func jsonValueToRaw(v interface{}) (raw.Value, error) {
 			return raw.NewFloat(f64), nil
 		}
 		return nil, fmt.Errorf("invalid JSON number %q", val.String())
-	case float64:
-		if math.Trunc(val) == val {
-			if val >= 0 {
-				return raw.NewPosInt(uint64(val)), nil
-			}
-			return raw.NewNegInt(int64(val)), nil
-		}
-		return raw.NewFloat(val), nil
 	case string:
 		return raw.NewQuotedString(val), nil
 	case bool:
 		return raw.NewBoolean(val), nil
 	case nil:
-		return &raw.NULL, nil
+		return &raw.Null{}, nil
 	default:
-		return nil, fmt.Errorf("unsupported JSON value %T", v)
+		return nil, fmt.Errorf("unsupported JSON value %T", token)
 	}
 }
 
@@ -261,6 +542,7 @@ type fileSyntax int
 const (
 	syntaxHocon fileSyntax = iota
 	syntaxJSON
+	syntaxProperties
 )
 
 type fileCandidate struct {
@@ -275,6 +557,8 @@ func buildFileCandidates(path string) []fileCandidate {
 		return []fileCandidate{{path: path, syntax: syntaxHocon}}
 	case ".json":
 		return []fileCandidate{{path: path, syntax: syntaxJSON}}
+	case ".properties":
+		return []fileCandidate{{path: path, syntax: syntaxProperties}}
 	default:
 		if ext != "" {
 			return []fileCandidate{{path: path, syntax: syntaxHocon}}
@@ -283,6 +567,7 @@ func buildFileCandidates(path string) []fileCandidate {
 			{path: path, syntax: syntaxHocon},
 			{path: path + ".conf", syntax: syntaxHocon},
 			{path: path + ".json", syntax: syntaxJSON},
+			{path: path + ".properties", syntax: syntaxProperties},
 		}
 	}
 }
@@ -1,5 +1,10 @@
 package parser
 
+import (
+	"hocon-go/common"
+	"io/fs"
+)
+
 const (
 	defaultMaxDepth        = 64
 	defaultMaxIncludeDepth = 64
@@ -7,15 +12,45 @@ const (
 
 // ConfigOptions contains parser level configuration flags.
 type ConfigOptions struct {
+	// UseSystemEnvironment lets substitutions fall back to environment variables.
 	UseSystemEnvironment bool
-	Classpath            []string
-	MaxDepth             int
-	MaxIncludeDepth      int
+	// SubstitutionSources are consulted, in order, for substitutions not defined
+	// in the configuration. They are tried before the system environment.
+	SubstitutionSources []common.SubstitutionSource
+	// Classpath lists the directories searched by classpath() includes, and
+	// by plain includes not found next to the including document.
+	Classpath []string
+	// ClasspathFS lists file systems searched after the Classpath
+	// directories, such as an embed.FS holding default settings.
+	ClasspathFS     []fs.FS
+	MaxDepth        int
+	MaxIncludeDepth int
+	// Overrides are "path=value" assignments applied after the document, in
+	// order, so they take precedence over its own fields. Values are read as
+	// HOCON when they parse as a single value and as plain strings otherwise.
+	Overrides []string
+	// EnvOverridePrefix enables the environment override layer when set, for
+	// example to "CONFIG_FORCE_". Each variable starting with it sets the
+	// path spelled by the rest of its name, with "_" standing for ".", "__"
+	// for "-" and "___" for "_". The layer is applied after Overrides.
+	EnvOverridePrefix string
+	// Environment holds the variables read by the override layer and, in
+	// LoadDefault, the CONFIG_FILE and CONFIG_RESOURCE variables. When nil,
+	// the process environment is used.
+	Environment map[string]string
+	// Fetcher retrieves url() includes and documents loaded by URL. When nil,
+	// the HTTPFetcher returned by NewHTTPFetcher is used.
+	Fetcher Fetcher
+	// Trace records, while the configuration is resolved, every definition of
+	// each path and every substitution, so that Config.Explain can report
+	// where a value came from. It costs memory and is off by default.
+	Trace bool
 }
 
 func DefaultConfigOptions() ConfigOptions {
 	return ConfigOptions{
 		UseSystemEnvironment: false,
+		SubstitutionSources:  nil,
 		Classpath:            nil,
 		MaxDepth:             defaultMaxDepth,
 		MaxIncludeDepth:      defaultMaxIncludeDepth,
@@ -31,3 +66,13 @@ func normalizeOptions(opts ConfigOptions) ConfigOptions {
 	}
 	return opts
 }
+
+// ResolveSources returns the substitution sources selected by the options.
+func (o ConfigOptions) ResolveSources() []common.SubstitutionSource {
+	sources := make([]common.SubstitutionSource, 0, len(o.SubstitutionSources)+1)
+	sources = append(sources, o.SubstitutionSources...)
+	if o.UseSystemEnvironment {
+		sources = append(sources, common.EnvSource{})
+	}
+	return sources
+}
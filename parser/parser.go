@@ -3,8 +3,10 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"hocon-go/common"
 	"hocon-go/raw"
 	"io"
+	"io/fs"
 	"strings"
 	"unicode/utf8"
 )
@@ -15,7 +17,18 @@ type Parser struct {
 	options ConfigOptions
 	depth   int
 	baseDir string
-	ctx     includeContext
+	// baseURL is the address of a fetched document, against which its
+	// relative includes are resolved.
+	baseURL string
+	// fsys is the file system a document read from an fs.FS came from;
+	// baseDir is then a directory within it.
+	fsys     fs.FS
+	ctx      includeContext
+	filename string
+	// recovering is set by ParseWithDiagnostics, which collects errors in
+	// diagnostics instead of stopping at the first one.
+	recovering  bool
+	diagnostics []Diagnostic
 }
 
 func NewParser(data []byte) *Parser {
@@ -38,7 +51,66 @@ func (p *Parser) WithOptions(opts ConfigOptions) *Parser {
 	return p
 }
 
+// WithFilename sets the name recorded in the origin of parsed values when the
+// document was not read through ParseFile.
+func (p *Parser) WithFilename(name string) *Parser {
+	p.filename = name
+	return p
+}
+
+// originAt describes the position of the byte at offset in the current document.
+func (p *Parser) originAt(offset int) *common.Origin {
+	line, column := p.reader.position(offset)
+	origin := &common.Origin{File: p.filename, Line: line, Column: column, Offset: offset}
+	if n := len(p.ctx.chain); n > 0 {
+		origin.File = p.ctx.chain[n-1]
+		origin.Includes = p.ctx.chain[:n-1]
+	}
+	return origin
+}
+
+// Parse parses the whole document. Malformed input is reported as a *SyntaxError.
 func (p *Parser) Parse() (*raw.Object, error) {
+	obj, err := p.parseDocument()
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	return obj, nil
+}
+
+// ParseValue parses the input as a single field value, such as the right-hand
+// side of "key = value": a primitive, string, array, object, substitution or a
+// concatenation of them. Anything but whitespace after the value is a
+// *SyntaxError, comments included, so that text such as "/usr//lib" is never
+// cut short at what would start a comment in a document.
+func (p *Parser) ParseValue() (raw.Value, error) {
+	value, err := p.parseValue()
+	if err == nil {
+		err = p.dropWhitespace()
+	}
+	if err == nil {
+		if ch, peekErr := p.reader.peek(); peekErr == nil {
+			err = &unexpectedTokenError{Expected: "end of input", Found: ch}
+		}
+	}
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	return value, nil
+}
+
+func (p *Parser) expectEnd() error {
+	if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if ch, err := p.reader.peek(); err == nil {
+		return &unexpectedTokenError{Expected: "end of input", Found: ch}
+	}
+	return nil
+}
+
+func (p *Parser) parseDocument() (*raw.Object, error) {
+	start := p.reader.idx
 	if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
 		return nil, err
 	}
@@ -48,6 +120,8 @@ func (p *Parser) Parse() (*raw.Object, error) {
 	case err == nil && ch == '{':
 		obj, err = p.parseObject(false)
 	case err == nil:
+		// Rewind so that leading comments are kept as comment fields.
+		p.reader.idx = start
 		obj, err = p.parseBracesOmittedObject()
 	case errors.Is(err, io.EOF):
 		return raw.NewObject(nil), nil
@@ -56,13 +130,22 @@ func (p *Parser) Parse() (*raw.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
-		return nil, err
-	}
-	if _, err := p.reader.peek(); err == nil {
-		return nil, fmt.Errorf("unexpected trailing content")
+	for {
+		err := p.expectEnd()
+		if err == nil {
+			return obj, nil
+		}
+		if !p.recordError(err) {
+			return nil, err
+		}
+		// Skip the stray byte and read what follows as more fields.
+		_ = p.reader.discard(1)
+		rest, err := p.parseBracesOmittedObject()
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields = append(obj.Fields, rest.Fields...)
 	}
-	return obj, nil
 }
 
 func (p *Parser) dropWhitespace() error {
@@ -249,6 +332,7 @@ func (p *Parser) parseCommentToken() (raw.CommentType, error) {
 }
 
 func (p *Parser) parseObjectField() (raw.ObjectField, error) {
+	start := p.reader.idx
 	ch, err := p.reader.peek()
 	if err != nil {
 		return nil, err
@@ -260,20 +344,25 @@ func (p *Parser) parseObjectField() (raw.ObjectField, error) {
 			if err != nil {
 				return nil, err
 			}
-			if err := p.parseInclusion(inclusion); err != nil {
+			if err := p.parseInclusion(inclusion, start); err != nil && !p.recordIncludeError(err, start) {
 				return nil, err
 			}
-			return raw.NewInclusionField(*inclusion), nil
+			field := &raw.InclusionField{Inclusion: *inclusion}
+			field.SetOrigin(p.originAt(start))
+			return field, nil
 		}
 	}
 	key, value, err := p.parseKeyValue()
 	if err != nil {
 		return nil, err
 	}
-	return raw.NewKeyValueField(key, value), nil
+	field := &raw.KeyValueField{Key: key, Value: value}
+	field.SetOrigin(p.originAt(start))
+	return field, nil
 }
 
 func (p *Parser) parseObject(verifyDelimiter bool) (*raw.Object, error) {
+	start := p.reader.idx
 	if verifyDelimiter {
 		ch, err := p.reader.peek()
 		if err != nil {
@@ -290,13 +379,17 @@ func (p *Parser) parseObject(verifyDelimiter bool) (*raw.Object, error) {
 	if err != nil {
 		return nil, err
 	}
+	obj.SetOrigin(p.originAt(start))
 	ch, err := p.reader.peek()
+	if err == nil && ch != '}' {
+		err = &unexpectedTokenError{Expected: "}", Found: ch}
+	}
 	if err != nil {
+		if p.recordError(err) {
+			return obj, nil
+		}
 		return nil, err
 	}
-	if ch != '}' {
-		return nil, &unexpectedTokenError{Expected: "}", Found: ch}
-	}
 	if err := p.reader.discard(1); err != nil {
 		return nil, err
 	}
@@ -304,11 +397,16 @@ func (p *Parser) parseObject(verifyDelimiter bool) (*raw.Object, error) {
 }
 
 func (p *Parser) parseBracesOmittedObject() (*raw.Object, error) {
+	start := p.reader.idx
 	fields := make([]raw.ObjectField, 0)
 	for {
-		if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
+		comments, err := p.collectComments()
+		if err != nil {
 			return nil, err
 		}
+		for _, c := range comments {
+			fields = append(fields, c.field())
+		}
 		ch, err := p.reader.peek()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -321,24 +419,120 @@ func (p *Parser) parseBracesOmittedObject() (*raw.Object, error) {
 		}
 		field, err := p.parseObjectField()
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+			if !p.recordError(err) {
+				return nil, err
 			}
-			return nil, err
+			p.resync(",\n}")
+			continue
 		}
 		fields = append(fields, field)
-		if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
+		if fields, err = p.collectFieldComments(field, fields); err != nil {
 			return nil, err
 		}
-		stop, err := p.dropCommaSeparator()
+		comma, err := p.reader.peek()
+		if err == nil && comma == ',' {
+			_ = p.reader.discard(1)
+			if fields, err = p.collectFieldComments(field, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	obj := raw.NewObject(fields)
+	obj.SetOrigin(p.originAt(start))
+	return obj, nil
+}
+
+// pendingComment is a comment read between object fields.
+type pendingComment struct {
+	comment raw.Comment
+	// afterNewline is set when a line break precedes the comment, i.e. it does
+	// not trail the previous field.
+	afterNewline bool
+	origin       *common.Origin
+}
+
+func (c pendingComment) field() raw.ObjectField {
+	field := &raw.NewlineCommentField{Comment: c.comment}
+	field.SetOrigin(c.origin)
+	return field
+}
+
+// collectComments skips whitespace and returns the comments found in it.
+func (p *Parser) collectComments() ([]pendingComment, error) {
+	var comments []pendingComment
+	afterNewline := false
+	for {
+		rn, size, err := p.reader.peekRune()
 		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return comments, nil
+			}
 			return nil, err
 		}
-		if stop {
-			break
+		if isWhitespace(rn) {
+			if rn == '\n' {
+				afterNewline = true
+			}
+			_ = p.reader.discard(size)
+			continue
+		}
+		if !p.startsWithComment() {
+			return comments, nil
+		}
+		start := p.reader.idx
+		ty, content, err := p.parseComment()
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, pendingComment{
+			comment:      *raw.NewComment(content, ty),
+			afterNewline: afterNewline,
+			origin:       p.originAt(start),
+		})
+	}
+}
+
+// collectFieldComments reads the comments following field. A comment on the
+// same line becomes the field's comment, the others become comment fields.
+func (p *Parser) collectFieldComments(field raw.ObjectField, fields []raw.ObjectField) ([]raw.ObjectField, error) {
+	comments, err := p.collectComments()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		if !c.afterNewline && fieldComment(field) == nil {
+			field.SetComment(c.comment)
+			continue
 		}
+		fields = append(fields, c.field())
 	}
-	return raw.NewObject(fields), nil
+	return fields, nil
+}
+
+func fieldComment(field raw.ObjectField) *raw.Comment {
+	switch f := field.(type) {
+	case *raw.KeyValueField:
+		return f.Comment
+	case *raw.InclusionField:
+		return f.Comment
+	default:
+		return nil
+	}
+}
+
+func (p *Parser) startsWithComment() bool {
+	ch, err := p.reader.peek()
+	if err != nil {
+		return false
+	}
+	if ch == '#' {
+		return true
+	}
+	if ch == '/' {
+		_, ch2, err := p.reader.peek2()
+		return err == nil && ch2 == '/'
+	}
+	return false
 }
 
 func (p *Parser) dropCommaSeparator() (bool, error) {
@@ -358,6 +552,7 @@ func (p *Parser) dropCommaSeparator() (bool, error) {
 }
 
 func (p *Parser) parseArray(verifyDelimiter bool) (*raw.Array, error) {
+	start := p.reader.idx
 	if verifyDelimiter {
 		ch, err := p.reader.peek()
 		if err != nil {
@@ -376,7 +571,13 @@ func (p *Parser) parseArray(verifyDelimiter bool) (*raw.Array, error) {
 			return nil, err
 		}
 		ch, err := p.reader.peek()
+		if err == nil && ch == '}' && p.recovering {
+			err = &unexpectedTokenError{Expected: "]", Found: ch}
+		}
 		if err != nil {
+			if p.recordError(err) {
+				break
+			}
 			return nil, err
 		}
 		if ch == ']' {
@@ -385,7 +586,11 @@ func (p *Parser) parseArray(verifyDelimiter bool) (*raw.Array, error) {
 		}
 		val, err := p.parseValue()
 		if err != nil {
-			return nil, err
+			if !p.recordError(err) {
+				return nil, err
+			}
+			p.resync(",\n]}")
+			continue
 		}
 		values = append(values, val)
 		if err := p.dropWhitespaceAndComments(); err != nil && !errors.Is(err, io.EOF) {
@@ -396,10 +601,16 @@ func (p *Parser) parseArray(verifyDelimiter bool) (*raw.Array, error) {
 			return nil, err
 		}
 		if stop {
-			break
+			// The input ended before the closing bracket.
+			if p.recordError(errEOF) {
+				break
+			}
+			return nil, errEOF
 		}
 	}
-	return raw.NewRawArray(values), nil
+	arr := raw.NewRawArray(values)
+	arr.SetOrigin(p.originAt(start))
+	return arr, nil
 }
 
 func (p *Parser) parseValue() (raw.Value, error) {
@@ -409,7 +620,8 @@ func (p *Parser) parseValue() (raw.Value, error) {
 	var values []raw.Value
 	var spaces []*string
 	var prevSpace *string
-	push := func(val raw.Value) {
+	push := func(val raw.Value, start int) {
+		val.SetOrigin(p.originAt(start))
 		if len(values) > 0 {
 			spaces = append(spaces, prevSpace)
 			prevSpace = nil
@@ -418,6 +630,7 @@ func (p *Parser) parseValue() (raw.Value, error) {
 	}
 
 	for {
+		start := p.reader.idx
 		ch, err := p.reader.peek()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -435,7 +648,7 @@ func (p *Parser) parseValue() (raw.Value, error) {
 			if err != nil {
 				return nil, err
 			}
-			push(arr)
+			push(arr, start)
 		case '{':
 			if err := p.increaseDepth(); err != nil {
 				return nil, err
@@ -445,19 +658,19 @@ func (p *Parser) parseValue() (raw.Value, error) {
 			if err != nil {
 				return nil, err
 			}
-			push(obj)
+			push(obj, start)
 		case '"':
 			strVal, err := p.parsePossibleMultilineString()
 			if err != nil {
 				return nil, err
 			}
-			push(strVal)
+			push(strVal, start)
 		case '$':
 			subst, err := p.parseSubstitution()
 			if err != nil {
 				return nil, err
 			}
-			push(subst)
+			push(subst, start)
 		case '}', ']':
 			goto done
 		case ',', '#', '\n', '\r':
@@ -490,7 +703,7 @@ func (p *Parser) parseValue() (raw.Value, error) {
 				if err != nil {
 					return nil, err
 				}
-				push(unquoted)
+				push(unquoted, start)
 			}
 		}
 	}
@@ -499,12 +712,15 @@ done:
 	case 0:
 		return nil, errors.New("expected value")
 	case 1:
-		return p.resolveUnquotedString(values[0]), nil
+		val := p.resolveUnquotedString(values[0])
+		val.SetOrigin(values[0].Origin())
+		return val, nil
 	default:
 		concat, err := raw.NewConcat(values, spaces)
 		if err != nil {
 			return nil, err
 		}
+		concat.SetOrigin(values[0].Origin())
 		return concat, nil
 	}
 }
@@ -540,7 +756,7 @@ func (p *Parser) resolveUnquotedString(val raw.Value) raw.Value {
 		case "false":
 			return raw.NewBoolean(false)
 		case "null":
-			return &raw.NULL
+			return &raw.Null{}
 		default:
 			if number, err := raw.ParseNumber(v.Value); err == nil {
 				switch n := number.(type) {
@@ -582,7 +798,9 @@ func (p *Parser) parseKeyValue() (raw.String, raw.Value, error) {
 		return nil, nil, err
 	}
 	if addAssign {
-		val = raw.NewAddAssign(val)
+		add := raw.NewAddAssign(val)
+		add.SetOrigin(val.Origin())
+		val = add
 	}
 	return key, val, nil
 }
@@ -1000,7 +1218,7 @@ func (p *Parser) parseUnquotedPathSegment() (string, error) {
 				break
 			}
 		}
-		if p.startsWithHorizontalWhitespace() {
+		if ch == '\n' || ch == '\r' || p.startsWithHorizontalWhitespace() {
 			break
 		}
 		if ForbiddenTable[ch] || ch == ',' || ch == ':' || ch == '=' || ch == '+' || ch == '{' || ch == '}' || ch == '[' || ch == ']' || ch == '#' {
@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"hocon-go/common"
+	"io"
+)
+
+// ParsePath splits a HOCON path expression such as `a.b."c.d"` into its
+// segments, using the same rules as keys and substitutions. A malformed path
+// is reported as a *common.BadValue, as Lightbend's BadPath is a BadValue.
+func ParsePath(path string) ([]string, error) {
+	p := NewParser([]byte(path))
+	key, err := p.parsePathExpression()
+	if err != nil {
+		return nil, &common.BadValue{Path: path, Reason: "invalid path: " + err.Error(), Err: err}
+	}
+	if _, err := p.reader.peek(); !errors.Is(err, io.EOF) {
+		return nil, &common.BadValue{Path: path, Reason: fmt.Sprintf("invalid path: unexpected trailing content %q", p.reader.remaining())}
+	}
+	return key.AsPath(), nil
+}
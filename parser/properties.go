@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/raw"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ParseProperties reads a Java properties document. Keys are split on '.'
+// into nested objects and every value is a string, as the HOCON spec requires.
+// When a key is used both for a value and as the parent of other keys, as in
+// a=1 and a.b=2, the object wins. Values are attributed to the line of their
+// key in the file named by filename.
+func ParseProperties(data []byte, filename string) (*raw.Object, error) {
+	return parseProperties(data, func(line int) *common.Origin {
+		return &common.Origin{File: filename, Line: line, Column: 1}
+	})
+}
+
+// propertyNode is a key of the document being built: a string value, an
+// object of further keys, or both until the object wins.
+type propertyNode struct {
+	value    *raw.QuotedString
+	keys     []string
+	children map[string]*propertyNode
+	origin   *common.Origin
+}
+
+func (n *propertyNode) child(key string, origin *common.Origin) *propertyNode {
+	if n.children == nil {
+		n.children = make(map[string]*propertyNode)
+	}
+	c, ok := n.children[key]
+	if !ok {
+		c = &propertyNode{origin: origin}
+		n.children[key] = c
+		n.keys = append(n.keys, key)
+	}
+	return c
+}
+
+func (n *propertyNode) object() *raw.Object {
+	fields := make([]raw.ObjectField, 0, len(n.keys))
+	for _, key := range n.keys {
+		c := n.children[key]
+		var value raw.Value = c.value
+		if c.children != nil {
+			value = c.object()
+		}
+		field := raw.NewKeyValueField(raw.NewQuotedString(key), value).(*raw.KeyValueField)
+		field.SetOrigin(c.origin)
+		fields = append(fields, field)
+	}
+	obj := raw.NewObject(fields)
+	obj.SetOrigin(n.origin)
+	return obj
+}
+
+func parseProperties(data []byte, originAt func(line int) *common.Origin) (*raw.Object, error) {
+	root := &propertyNode{origin: originAt(1)}
+	lines := strings.Split(strings.TrimPrefix(string(data), "\ufeff"), "\n")
+	for i := 0; i < len(lines); i++ {
+		start := i + 1
+		line := strings.TrimLeft(strings.TrimSuffix(lines[i], "\r"), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// A line ending in an odd number of backslashes continues on the next
+		// one, whose leading whitespace is dropped.
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(strings.TrimSuffix(lines[i], "\r"), " \t\f")
+		}
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return nil, newSyntaxError(originAt(start), err)
+		}
+		origin := originAt(start)
+		node := root
+		for _, part := range strings.Split(key, ".") {
+			node = node.child(part, origin)
+		}
+		str := raw.NewQuotedString(value)
+		str.SetOrigin(origin)
+		node.value = str
+		node.origin = origin
+	}
+	return root.object(), nil
+}
+
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into its unescaped key and value. The
+// key ends at the first unescaped '=', ':' or whitespace.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, ok := propertyUnicode(s[i+1:])
+			if !ok {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			i += 4
+			// Characters outside the BMP are written as surrogate pairs.
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, ok := propertyUnicode(s[i+3:]); ok {
+					if combined := utf16.DecodeRune(r, low); combined != utf8.RuneError {
+						r = combined
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// propertyUnicode decodes the four hex digits at the start of s.
+func propertyUnicode(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[:4], 16, 32)
+	return rune(r), err == nil
+}
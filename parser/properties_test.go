@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"hocon-go/raw"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	const input = "# comment\n" +
+		"! another comment\n" +
+		"a.b = 1\n" +
+		"a.c:two\n" +
+		"a.d three four\n" +
+		"  indented=yes\n" +
+		"multi = first \\\n" +
+		"        second\n" +
+		"escaped\\ key = tab\\there\n" +
+		"unicode = caf\\u00e9 \\ud83d\\ude00\n" +
+		"empty\n" +
+		"a = loses to the object\n" +
+		"path = C:\\\\dir\r\n"
+	obj, err := ParseProperties([]byte(input), "test.properties")
+	if err != nil {
+		t.Fatalf("ParseProperties: %v", err)
+	}
+	expected := map[string]string{
+		"a.b":         "1",
+		"a.c":         "two",
+		"a.d":         "three four",
+		"indented":    "yes",
+		"multi":       "first second",
+		"escaped key": "tab\there",
+		"unicode":     "café 😀",
+		"empty":       "",
+		"path":        `C:\dir`,
+	}
+	for path, want := range expected {
+		if got := valueAt(t, obj, path); got != want {
+			t.Errorf("%s = %q, expected %q", path, got, want)
+		}
+	}
+	a := obj.Fields[0].(*raw.KeyValueField)
+	if _, isObject := a.Value.(*raw.Object); !isObject {
+		t.Errorf("expected a to stay an object, got %s", a.Value)
+	}
+	if origin := a.Value.(*raw.Object).Fields[0].(*raw.KeyValueField).Value.Origin(); origin.File != "test.properties" || origin.Line != 3 {
+		t.Errorf("unexpected origin of a.b: %v", origin)
+	}
+}
+
+func TestParsePropertiesErrors(t *testing.T) {
+	if _, err := ParseProperties([]byte("a = \\u12"), "bad.properties"); err == nil {
+		t.Fatal("expected malformed escape error")
+	}
+}
+
+func TestIncludeProperties(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.conf":         "include \"jdbc\"\njdbc.pool = 10",
+		"jdbc.properties":   "jdbc.url=jdbc:postgresql://localhost/db\njdbc.pool=5",
+		"direct.properties": "x.y=z",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	obj, err := ParseFile(filepath.Join(dir, "main.conf"), DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if url := valueAt(t, obj, "jdbc.url"); url != "jdbc:postgresql://localhost/db" {
+		t.Fatalf("jdbc.url = %q", url)
+	}
+	obj, err = ParseFile(filepath.Join(dir, "direct.properties"), DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if y := valueAt(t, obj, "x.y"); y != "z" {
+		t.Fatalf("x.y = %q", y)
+	}
+}
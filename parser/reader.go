@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"io"
+	"sort"
 	"unicode/utf8"
 )
 
@@ -11,6 +12,9 @@ var errEOF = io.EOF
 type reader struct {
 	data []byte
 	idx  int
+	// lineStarts holds the offset of the first byte of every line. It is
+	// built on first use by position.
+	lineStarts []int
 }
 
 func newReader(data []byte) *reader {
@@ -79,3 +83,19 @@ func (r *reader) peekRune() (rune, int, error) {
 	}
 	return rn, size, nil
 }
+
+// position converts a byte offset into a 1-based line and byte column.
+func (r *reader) position(offset int) (int, int) {
+	if r.lineStarts == nil {
+		r.lineStarts = []int{0}
+		for i, b := range r.data {
+			if b == '\n' {
+				r.lineStarts = append(r.lineStarts, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(r.lineStarts), func(i int) bool {
+		return r.lineStarts[i] > offset
+	}) - 1
+	return line + 1, offset - r.lineStarts[line] + 1
+}
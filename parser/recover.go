@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"errors"
+	"hocon-go/common"
+	"hocon-go/raw"
+	"strings"
+)
+
+// Diagnostic is a problem found by ParseWithDiagnostics.
+type Diagnostic struct {
+	// Origin is where the problem was found. Problems in included documents
+	// name the included file.
+	Origin *common.Origin
+	// Message describes the problem without its position.
+	Message string
+	// Err is the error itself: a *SyntaxError, or one of the errors of the
+	// common package for includes that could not be loaded.
+	Err error
+	// Include is where the include statement that failed is, for problems
+	// found while loading an include, such as syntax errors in the included
+	// file. It is nil otherwise.
+	Include *common.Origin
+}
+
+func (d Diagnostic) Error() string {
+	return d.Err.Error()
+}
+
+func (d Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// ParseWithDiagnostics parses the whole document in recovery mode. Instead of
+// stopping at the first error it records it, skips ahead to the next newline,
+// comma or closing brace and goes on parsing. The result holds every field
+// that could be read; fields and array elements with errors are left out, and
+// failed includes are treated as empty. The diagnostics are in input order.
+func (p *Parser) ParseWithDiagnostics() (*raw.Object, []Diagnostic) {
+	p.recovering = true
+	p.diagnostics = nil
+	defer func() { p.recovering = false }()
+	obj, err := p.parseDocument()
+	if err != nil {
+		p.recordError(err)
+	}
+	if obj == nil {
+		obj = raw.NewObject(nil)
+	}
+	return obj, p.diagnostics
+}
+
+// recordError records err as a diagnostic at the current position and
+// reports whether parsing should go on, which is only in recovery mode.
+func (p *Parser) recordError(err error) bool {
+	if !p.recovering {
+		return false
+	}
+	diag := newDiagnostic(p.wrapError(err))
+	// An error at the end of the input is met again by every enclosing
+	// object and array; report it once.
+	if n := len(p.diagnostics); n > 0 && sameOrigin(p.diagnostics[n-1].Origin, diag.Origin) {
+		return true
+	}
+	p.diagnostics = append(p.diagnostics, diag)
+	return true
+}
+
+// recordIncludeError records err, met while loading the include statement at
+// offset start, like recordError.
+func (p *Parser) recordIncludeError(err error, start int) bool {
+	n := len(p.diagnostics)
+	if !p.recordError(err) {
+		return false
+	}
+	if len(p.diagnostics) > n {
+		p.diagnostics[n].Include = p.originAt(start)
+	}
+	return true
+}
+
+func newDiagnostic(err error) Diagnostic {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Diagnostic{Origin: syntaxErr.Origin(), Message: syntaxErr.message(), Err: err}
+	}
+	origin := errorOrigin(err)
+	message := err.Error()
+	if origin != nil {
+		message = strings.TrimPrefix(message, origin.String()+": ")
+	}
+	return Diagnostic{Origin: origin, Message: message, Err: err}
+}
+
+func sameOrigin(a, b *common.Origin) bool {
+	return a != nil && b != nil && a.File == b.File && a.Offset == b.Offset
+}
+
+// errorOrigin returns the position carried by the include errors.
+func errorOrigin(err error) *common.Origin {
+	var notFound *common.IncludeNotFound
+	var ioErr *common.IOError
+	switch {
+	case errors.As(err, &notFound):
+		return notFound.Origin
+	case errors.As(err, &ioErr):
+		return ioErr.Origin
+	}
+	return nil
+}
+
+// resync skips input up to the next byte in stops, outside quoted strings.
+// Newlines and commas are consumed; closing brackets are left for the
+// enclosing object or array to end on.
+func (p *Parser) resync(stops string) {
+	for {
+		ch, err := p.reader.peek()
+		if err != nil {
+			return
+		}
+		if strings.IndexByte(stops, ch) >= 0 {
+			if ch == '\n' || ch == ',' {
+				_ = p.reader.discard(1)
+			}
+			return
+		}
+		_ = p.reader.discard(1)
+		if ch == '"' {
+			p.skipQuotedLine()
+		}
+	}
+}
+
+// skipQuotedLine skips the rest of a quoted string, stopping early at the end
+// of the line for unterminated ones.
+func (p *Parser) skipQuotedLine() {
+	for {
+		ch, err := p.reader.peek()
+		if err != nil || ch == '\n' {
+			return
+		}
+		_ = p.reader.discard(1)
+		switch ch {
+		case '\\':
+			if next, err := p.reader.peek(); err == nil && next != '\n' {
+				_ = p.reader.discard(1)
+			}
+		case '"':
+			return
+		}
+	}
+}
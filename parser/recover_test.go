@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"errors"
+	"hocon-go/common"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWithDiagnostics(t *testing.T) {
+	input := `a = 1
+b = @@
+c = [1, 2, =, 4]
+d { x = 1, y = }, z = 3 }
+e = 5 = 6
+f = 6
+}
+g = 7
+h = [8,
+`
+	obj, diags := NewParser([]byte(input)).ParseWithDiagnostics()
+	for path, expected := range map[string]string{
+		"a":   "1",
+		"b":   "",
+		"c":   "[1, 2, 4]",
+		"d.x": "1",
+		"z":   "3",
+		"f":   "6",
+		"g":   "7",
+		"h":   "[8]",
+	} {
+		if value := valueAt(t, obj, path); value != expected {
+			t.Errorf("%s = %q, expected %q", path, value, expected)
+		}
+	}
+	expectedLines := []int{2, 3, 4, 4, 5, 7, 10}
+	if len(diags) != len(expectedLines) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expectedLines), len(diags), diags)
+	}
+	for i, diag := range diags {
+		if diag.Origin.Line != expectedLines[i] {
+			t.Errorf("diagnostic %d at line %d, expected %d: %v", i, diag.Origin.Line, expectedLines[i], diag)
+		}
+		if diag.Message == "" || strings.Contains(diag.Message, "<input>") {
+			t.Errorf("diagnostic %d has message %q", i, diag.Message)
+		}
+		var syntaxErr *SyntaxError
+		if !errors.As(diag, &syntaxErr) {
+			t.Errorf("diagnostic %d is not a SyntaxError: %v", i, diag)
+		}
+	}
+}
+
+func TestParseWithDiagnosticsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.conf"), []byte("x = ]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input := `include required("missing.conf"), a = 1
+include "broken.conf"
+b = 2
+`
+	p := newParser([]byte(input), DefaultConfigOptions(), dir, includeContext{})
+	obj, diags := p.ParseWithDiagnostics()
+	if valueAt(t, obj, "a") != "1" || valueAt(t, obj, "b") != "2" {
+		t.Fatalf("unexpected partial result %v", obj)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+	var notFound *common.IncludeNotFound
+	if !errors.As(diags[0], &notFound) || diags[0].Origin.Line != 1 {
+		t.Errorf("expected a missing include at line 1, got %v", diags[0])
+	}
+	if file := diags[1].Origin.File; filepath.Base(file) != "broken.conf" {
+		t.Errorf("expected the error in broken.conf, got %v", diags[1])
+	}
+	if include := diags[1].Include; include == nil || include.Line != 2 || include.Column != 1 {
+		t.Errorf("expected the include statement at line 2, got %v", include)
+	}
+}
+
+func TestParseStopsAtFirstError(t *testing.T) {
+	p := NewParser([]byte("a = @@\nb = [\n"))
+	if _, err := p.Parse(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(p.diagnostics) != 0 {
+		t.Fatalf("Parse recorded diagnostics: %v", p.diagnostics)
+	}
+}
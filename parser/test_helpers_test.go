@@ -1,5 +1,11 @@
 package parser
 
+import (
+	"hocon-go/common"
+	"hocon-go/raw"
+	"testing"
+)
+
 func newTestParser(input string) *Parser {
 	return NewParser([]byte(input))
 }
@@ -14,3 +20,16 @@ func remainingInput(p *Parser) string {
 	}
 	return string(rem)
 }
+
+// valueAt returns the text of the value at path in obj, or "" when it is unset.
+func valueAt(t *testing.T, obj *raw.Object, path string) string {
+	t.Helper()
+	key, err := common.FromStr(path)
+	if err != nil {
+		t.Fatalf("FromStr(%s): %v", path, err)
+	}
+	if value := obj.GetByPath(key); value != nil {
+		return value.String()
+	}
+	return ""
+}
@@ -1,6 +1,9 @@
 package raw
 
+import "hocon-go/common"
+
 type AddAssign struct {
+	common.Located
 	Val Value
 }
 
@@ -1,8 +1,12 @@
 package raw
 
-import "strings"
+import (
+	"hocon-go/common"
+	"strings"
+)
 
 type Array struct {
+	common.Located
 	Values []Value
 }
 
@@ -1,8 +1,12 @@
 package raw
 
-import "strconv"
+import (
+	"hocon-go/common"
+	"strconv"
+)
 
 type Boolean struct {
+	common.Located
 	Val bool
 }
 
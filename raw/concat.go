@@ -2,10 +2,12 @@ package raw
 
 import (
 	"fmt"
+	"hocon-go/common"
 	"strings"
 )
 
 type Concat struct {
+	common.Located
 	Values []Value
 	Spaces []*string
 }
@@ -1,6 +1,9 @@
 package raw
 
-import "fmt"
+import (
+	"fmt"
+	"hocon-go/common"
+)
 
 type ObjectField interface {
 	String() string
@@ -9,6 +12,7 @@ type ObjectField interface {
 }
 
 type InclusionField struct {
+	common.Located
 	Inclusion Inclusion
 	Comment   *Comment
 }
@@ -26,6 +30,7 @@ func (i *InclusionField) String() string {
 }
 
 type KeyValueField struct {
+	common.Located
 	Key     String
 	Value   Value
 	Comment *Comment
@@ -44,6 +49,7 @@ func (k *KeyValueField) String() string {
 }
 
 type NewlineCommentField struct {
+	common.Located
 	Comment Comment
 }
 
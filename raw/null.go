@@ -1,6 +1,9 @@
 package raw
 
+import "hocon-go/common"
+
 type Null struct {
+	common.Located
 }
 
 func (*Null) Type() string {
@@ -3,6 +3,7 @@ package raw
 import (
 	"errors"
 	"fmt"
+	"hocon-go/common"
 	"strconv"
 	"strings"
 )
@@ -12,6 +13,7 @@ type Number interface {
 }
 
 type PosInt struct {
+	common.Located
 	Val uint64
 }
 
@@ -29,6 +31,7 @@ func (p *PosInt) isRawValue() {
 }
 
 type NegInt struct {
+	common.Located
 	Val int64
 }
 
@@ -46,6 +49,7 @@ func (n *NegInt) isRawValue() {
 }
 
 type Float struct {
+	common.Located
 	Val float64
 }
 
@@ -3,6 +3,7 @@ package raw
 import "hocon-go/common"
 
 type Object struct {
+	common.Located
 	Fields []ObjectField
 }
 
@@ -109,7 +110,9 @@ func (o *Object) GetByPath(path *common.Path) Value {
 		switch f := field.(type) {
 		case *InclusionField:
 			if f.Inclusion.Val != nil {
-				return f.Inclusion.Val.GetByPath(path)
+				if value := f.Inclusion.Val.GetByPath(path); value != nil {
+					return value
+				}
 			}
 		case *KeyValueField:
 			k := f.Key.AsPath()
@@ -2,6 +2,7 @@ package raw
 
 import (
 	"fmt"
+	"hocon-go/common"
 	"strings"
 )
 
@@ -36,7 +37,10 @@ type String interface {
 	isRawString() // marker method
 }
 
-type QuotedString struct{ Value string }
+type QuotedString struct {
+	common.Located
+	Value string
+}
 
 func (*QuotedString) isRawString()       {}
 func (*QuotedString) isRawValue()        {}
@@ -44,7 +48,10 @@ func (s *QuotedString) String() string   { return s.Value }
 func (*QuotedString) Type() string       { return QuotedStringType }
 func (s *QuotedString) AsPath() []string { return []string{s.Value} }
 
-type UnquotedString struct{ Value string }
+type UnquotedString struct {
+	common.Located
+	Value string
+}
 
 func (*UnquotedString) isRawString()       {}
 func (*UnquotedString) isRawValue()        {}
@@ -52,7 +59,10 @@ func (s *UnquotedString) String() string   { return s.Value }
 func (s *UnquotedString) Type() string     { return UnquotedStringType }
 func (s *UnquotedString) AsPath() []string { return []string{s.Value} }
 
-type MultilineString struct{ Value string }
+type MultilineString struct {
+	common.Located
+	Value string
+}
 
 func (*MultilineString) isRawString()       {}
 func (*MultilineString) isRawValue()        {}
@@ -60,7 +70,10 @@ func (s *MultilineString) String() string   { return s.Value }
 func (s *MultilineString) Type() string     { return MultilineStringType }
 func (s *MultilineString) AsPath() []string { return []string{s.Value} }
 
-type PathExpressionString struct{ Value PathExpression }
+type PathExpressionString struct {
+	common.Located
+	Value PathExpression
+}
 
 func (*PathExpressionString) isRawString()     {}
 func (*PathExpressionString) isRawValue()      {}
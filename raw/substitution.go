@@ -1,8 +1,12 @@
 package raw
 
-import "fmt"
+import (
+	"fmt"
+	"hocon-go/common"
+)
 
 type Substitution struct {
+	common.Located
 	Path     String
 	Optional bool
 }
@@ -1,5 +1,7 @@
 package raw
 
+import "hocon-go/common"
+
 const (
 	ObjectType          = "object"
 	ArrayType           = "array"
@@ -18,5 +20,7 @@ const (
 type Value interface {
 	Type() string
 	String() string
+	Origin() *common.Origin
+	SetOrigin(origin *common.Origin)
 	isRawValue()
 }
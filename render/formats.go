@@ -0,0 +1,223 @@
+package render
+
+import (
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/raw"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// YAML renders a resolved value as block-style YAML, keeping the definition
+// order of object keys.
+func YAML(v merge.Value) (string, error) {
+	var b strings.Builder
+	switch val := v.(type) {
+	case *merge.Object:
+		if len(visibleKeys(val)) == 0 {
+			return "{}\n", nil
+		}
+		if err := writeYAMLObject(&b, val, 0); err != nil {
+			return "", err
+		}
+	case *merge.Array:
+		if len(val.Values) == 0 {
+			return "[]\n", nil
+		}
+		if err := writeYAMLArray(&b, val, -1); err != nil {
+			return "", err
+		}
+	default:
+		s, err := yamlScalar(v)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func writeYAMLObject(b *strings.Builder, obj *merge.Object, indent int) error {
+	for _, key := range visibleKeys(obj) {
+		b.WriteString(strings.Repeat("  ", indent))
+		b.WriteString(yamlString(key))
+		b.WriteByte(':')
+		if err := writeYAMLValue(b, obj.Values[key], indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeYAMLArray(b *strings.Builder, arr *merge.Array, indent int) error {
+	for _, item := range arr.Values {
+		b.WriteString(strings.Repeat("  ", indent+1))
+		if obj, ok := item.(*merge.Object); ok && len(visibleKeys(obj)) > 0 {
+			// Start the mapping on the dash line, as in "- key: value".
+			var nested strings.Builder
+			if err := writeYAMLObject(&nested, obj, indent+2); err != nil {
+				return err
+			}
+			b.WriteString("- ")
+			b.WriteString(strings.TrimPrefix(nested.String(), strings.Repeat("  ", indent+2)))
+			continue
+		}
+		b.WriteByte('-')
+		if err := writeYAMLValue(b, item, indent+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLValue writes the value following a "key:" or "-" marker.
+func writeYAMLValue(b *strings.Builder, v merge.Value, indent int) error {
+	switch val := v.(type) {
+	case *merge.Object:
+		if len(visibleKeys(val)) == 0 {
+			b.WriteString(" {}\n")
+			return nil
+		}
+		b.WriteByte('\n')
+		return writeYAMLObject(b, val, indent+1)
+	case *merge.Array:
+		if len(val.Values) == 0 {
+			b.WriteString(" []\n")
+			return nil
+		}
+		b.WriteByte('\n')
+		return writeYAMLArray(b, val, indent)
+	default:
+		s, err := yamlScalar(v)
+		if err != nil {
+			return err
+		}
+		b.WriteByte(' ')
+		b.WriteString(s)
+		b.WriteByte('\n')
+		return nil
+	}
+}
+
+// yamlPlain matches strings that YAML reads back unchanged without quotes.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ./-]*$`)
+
+// yamlReserved lists plain scalars YAML would read as something other than a string.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlReserved[strings.ToLower(s)] && !strings.HasSuffix(s, " ") {
+		return s
+	}
+	// JSON strings are valid double-quoted YAML scalars.
+	return Quote(s)
+}
+
+func yamlScalar(v merge.Value) (string, error) {
+	switch val := v.(type) {
+	case *merge.String:
+		return yamlString(val.Val), nil
+	case *merge.Number:
+		if f, ok := val.N.(*raw.Float); ok {
+			switch {
+			case math.IsInf(f.Val, 1):
+				return ".inf", nil
+			case math.IsInf(f.Val, -1):
+				return "-.inf", nil
+			case math.IsNaN(f.Val):
+				return ".nan", nil
+			}
+		}
+		return formatNumber(val.N), nil
+	case *merge.Boolean:
+		return strconv.FormatBool(val.Val), nil
+	case *merge.Null, *merge.None:
+		return "null", nil
+	default:
+		return "", &common.BadValue{Reason: fmt.Sprintf("cannot render unresolved value %s", v.String()), Origin: v.Origin()}
+	}
+}
+
+// Properties renders a resolved object as Java properties with dotted keys.
+// Array elements become numbered keys. Null values have no representation in
+// properties and are left out.
+func Properties(v merge.Value) (string, error) {
+	var b strings.Builder
+	if err := writeProperties(&b, "", v); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeProperties(b *strings.Builder, prefix string, v merge.Value) error {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch val := v.(type) {
+	case *merge.Object:
+		for _, key := range visibleKeys(val) {
+			if err := writeProperties(b, join(key), val.Values[key]); err != nil {
+				return err
+			}
+		}
+	case *merge.Array:
+		for i, item := range val.Values {
+			if err := writeProperties(b, join(strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+	case *merge.Null, *merge.None:
+	case *merge.String, *merge.Number, *merge.Boolean:
+		text := val.String()
+		if s, ok := val.(*merge.String); ok {
+			text = s.Val
+		} else if n, ok := val.(*merge.Number); ok {
+			text = formatNumber(n.N)
+		}
+		b.WriteString(escapeProperty(prefix, true))
+		b.WriteByte('=')
+		b.WriteString(escapeProperty(text, false))
+		b.WriteByte('\n')
+	default:
+		return &common.BadValue{Reason: fmt.Sprintf("cannot render unresolved value %s", v.String()), Origin: v.Origin()}
+	}
+	return nil
+}
+
+// escapeProperty escapes s for a properties file. Keys additionally escape
+// separators and spaces; values only escape them at the start.
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case ' ', '=', ':', '#', '!':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
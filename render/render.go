@@ -0,0 +1,495 @@
+// Package render writes configuration trees back out as HOCON or JSON text.
+package render
+
+import (
+	"fmt"
+	"hocon-go/common"
+	"hocon-go/merge"
+	"hocon-go/raw"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options mirror Lightbend's ConfigRenderOptions.
+type Options struct {
+	// JSON renders strict JSON. Comments are never written in this mode and
+	// unresolved values cannot be rendered.
+	JSON bool
+	// Formatted spreads the output over several indented lines.
+	Formatted bool
+	// Comments keeps the comments recorded in unresolved documents.
+	Comments bool
+	// OriginComments writes a comment describing where each field was defined.
+	OriginComments bool
+	// SortKeys writes object keys in lexical order instead of definition order.
+	SortKeys bool
+	// Indent is the indentation unit for formatted output, two spaces by default.
+	Indent string
+}
+
+// DefaultOptions renders formatted HOCON with comments and origin comments.
+func DefaultOptions() Options {
+	return Options{Formatted: true, Comments: true, OriginComments: true}
+}
+
+// ConciseOptions renders compact JSON without any comments.
+func ConciseOptions() Options {
+	return Options{JSON: true}
+}
+
+// Value renders a resolved value.
+func Value(v merge.Value, opts Options) (string, error) {
+	w := newWriter(opts)
+	if err := w.mergeValue(v, 0, true); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// Raw renders an unresolved document as it was parsed, keeping substitutions,
+// concatenations and include statements.
+func Raw(obj *raw.Object, opts Options) (string, error) {
+	w := newWriter(opts)
+	if err := w.rawObject(obj, 0, true); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// RawValue renders an unresolved value, such as the value of a field as it
+// was written.
+func RawValue(v raw.Value, opts Options) (string, error) {
+	w := newWriter(opts)
+	if err := w.rawValue(v, 0); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+type writer struct {
+	strings.Builder
+	opts Options
+}
+
+func newWriter(opts Options) *writer {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	return &writer{opts: opts}
+}
+
+// omitRootBraces reports whether the root object is written without braces,
+// which HOCON allows and which reads more naturally in formatted output.
+func (w *writer) omitRootBraces(root bool) bool {
+	return root && !w.opts.JSON && w.opts.Formatted
+}
+
+func (w *writer) newline(depth int) {
+	if !w.opts.Formatted {
+		return
+	}
+	w.WriteByte('\n')
+	w.WriteString(strings.Repeat(w.opts.Indent, depth))
+}
+
+func (w *writer) separator() string {
+	switch {
+	case w.opts.JSON && w.opts.Formatted:
+		return ": "
+	case w.opts.JSON:
+		return ":"
+	case w.opts.Formatted:
+		return " = "
+	default:
+		return "="
+	}
+}
+
+func (w *writer) originComment(origin *common.Origin, depth int) {
+	if !w.opts.OriginComments || w.opts.JSON || !w.opts.Formatted || origin == nil {
+		return
+	}
+	w.WriteString("# ")
+	w.WriteString(origin.String())
+	w.newline(depth)
+}
+
+func (w *writer) mergeValue(v merge.Value, depth int, root bool) error {
+	switch val := v.(type) {
+	case *merge.Object:
+		return w.mergeObject(val, depth, root)
+	case *merge.Array:
+		if len(val.Values) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteByte('[')
+		for i, item := range val.Values {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.newline(depth + 1)
+			if err := w.mergeValue(item, depth+1, false); err != nil {
+				return err
+			}
+		}
+		w.newline(depth)
+		w.WriteByte(']')
+	case *merge.String:
+		w.WriteString(Quote(val.Val))
+	case *merge.Number:
+		w.WriteString(formatNumber(val.N))
+	case *merge.Boolean:
+		w.WriteString(strconv.FormatBool(val.Val))
+	case *merge.Null, *merge.None:
+		w.WriteString("null")
+	default:
+		return &common.BadValue{Reason: fmt.Sprintf("cannot render unresolved value %s", v.String()), Origin: v.Origin()}
+	}
+	return nil
+}
+
+func (w *writer) mergeObject(obj *merge.Object, depth int, root bool) error {
+	keys := visibleKeys(obj)
+	if w.opts.SortKeys {
+		sort.Strings(keys)
+	}
+
+	bare := w.omitRootBraces(root)
+	inner := depth + 1
+	if bare {
+		inner = depth
+	}
+	if !bare {
+		if len(keys) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+		w.WriteByte('{')
+	}
+	for i, key := range keys {
+		if i > 0 {
+			if !w.opts.Formatted || w.opts.JSON {
+				w.WriteByte(',')
+			}
+		}
+		if !bare || i > 0 {
+			w.newline(inner)
+		}
+		child := obj.Values[key]
+		w.originComment(child.Origin(), inner)
+		w.WriteString(w.key(key))
+		if _, isObj := child.(*merge.Object); isObj && !w.opts.JSON {
+			w.WriteByte(' ')
+		} else {
+			w.WriteString(w.separator())
+		}
+		if err := w.mergeValue(child, inner, false); err != nil {
+			return err
+		}
+	}
+	if !bare {
+		w.newline(depth)
+		w.WriteByte('}')
+	} else if w.opts.Formatted && len(keys) > 0 {
+		w.WriteByte('\n')
+	}
+	return nil
+}
+
+// visibleKeys returns the keys of obj in definition order, leaving out
+// optional substitutions that did not resolve.
+func visibleKeys(obj *merge.Object) []string {
+	keys := make([]string, 0, len(obj.Values))
+	for _, k := range obj.Keys() {
+		if _, isNone := obj.Values[k].(*merge.None); !isNone {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (w *writer) rawObject(obj *raw.Object, depth int, root bool) error {
+	fields := w.rawFields(obj)
+	bare := w.omitRootBraces(root)
+	inner := depth + 1
+	if bare {
+		inner = depth
+	}
+	if !bare {
+		if len(fields) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+		w.WriteByte('{')
+	}
+	wroteField := false
+	for i, field := range fields {
+		_, isComment := field.(*raw.NewlineCommentField)
+		if wroteField && !isComment && (!w.opts.Formatted || w.opts.JSON) {
+			w.WriteByte(',')
+		}
+		if !bare || i > 0 {
+			w.newline(inner)
+		}
+		switch f := field.(type) {
+		case *raw.NewlineCommentField:
+			w.comment(f.Comment)
+			continue
+		case *raw.KeyValueField:
+			w.originComment(f.Origin(), inner)
+			if err := w.rawField(f, inner); err != nil {
+				return err
+			}
+			if f.Comment != nil && w.commentsEnabled() {
+				w.WriteByte(' ')
+				w.comment(*f.Comment)
+			}
+		case *raw.InclusionField:
+			if w.opts.JSON {
+				return &common.BadValue{Reason: fmt.Sprintf("cannot render %s as JSON", f.Inclusion.String()), Origin: f.Origin()}
+			}
+			w.WriteString(renderInclusion(f.Inclusion))
+			if f.Comment != nil && w.commentsEnabled() {
+				w.WriteByte(' ')
+				w.comment(*f.Comment)
+			}
+		}
+		wroteField = true
+	}
+	if !bare {
+		w.newline(depth)
+		w.WriteByte('}')
+	} else if w.opts.Formatted && len(fields) > 0 {
+		w.WriteByte('\n')
+	}
+	return nil
+}
+
+// rawFields returns the fields to render, dropping comments when they are
+// disabled and sorting key-value fields when requested.
+func (w *writer) rawFields(obj *raw.Object) []raw.ObjectField {
+	fields := make([]raw.ObjectField, 0, len(obj.Fields))
+	for _, field := range obj.Fields {
+		if _, isComment := field.(*raw.NewlineCommentField); isComment && !w.commentsEnabled() {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if w.opts.SortKeys {
+		// Sorting is stable so repeated keys keep their relative order, which
+		// decides which definition wins.
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fieldSortKey(fields[i]) < fieldSortKey(fields[j])
+		})
+	}
+	return fields
+}
+
+func fieldSortKey(field raw.ObjectField) string {
+	if kv, ok := field.(*raw.KeyValueField); ok {
+		return strings.Join(kv.Key.AsPath(), ".")
+	}
+	// Includes and comments are kept ahead of keys so includes stay overridable.
+	return ""
+}
+
+func (w *writer) commentsEnabled() bool {
+	return w.opts.Comments && !w.opts.JSON && w.opts.Formatted
+}
+
+func (w *writer) comment(c raw.Comment) {
+	w.WriteString(c.String())
+}
+
+func (w *writer) rawField(f *raw.KeyValueField, depth int) error {
+	w.WriteString(w.path(f.Key.AsPath()))
+	value := f.Value
+	if add, ok := value.(*raw.AddAssign); ok {
+		if w.opts.JSON {
+			return &common.BadValue{Reason: "cannot render += as JSON", Origin: f.Origin()}
+		}
+		w.WriteString(" += ")
+		return w.rawValue(add.Val, depth)
+	}
+	if _, isObj := value.(*raw.Object); isObj && !w.opts.JSON {
+		w.WriteByte(' ')
+	} else {
+		w.WriteString(w.separator())
+	}
+	return w.rawValue(value, depth)
+}
+
+func (w *writer) rawValue(v raw.Value, depth int) error {
+	switch val := v.(type) {
+	case *raw.Object:
+		return w.rawObject(val, depth, false)
+	case *raw.Array:
+		if len(val.Values) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteByte('[')
+		for i, item := range val.Values {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.newline(depth + 1)
+			if err := w.rawValue(item, depth+1); err != nil {
+				return err
+			}
+		}
+		w.newline(depth)
+		w.WriteByte(']')
+	case *raw.QuotedString:
+		w.WriteString(Quote(val.Value))
+	case *raw.UnquotedString:
+		if w.opts.JSON {
+			w.WriteString(Quote(val.Value))
+		} else {
+			w.WriteString(val.Value)
+		}
+	case *raw.MultilineString:
+		if w.opts.JSON || strings.Contains(val.Value, `"""`) {
+			w.WriteString(Quote(val.Value))
+		} else {
+			w.WriteString(`"""` + val.Value + `"""`)
+		}
+	case *raw.PathExpressionString:
+		w.WriteString(Quote(val.String()))
+	case *raw.PosInt, *raw.NegInt, *raw.Float:
+		w.WriteString(formatNumber(val.(raw.Number)))
+	case *raw.Boolean:
+		w.WriteString(strconv.FormatBool(val.Val))
+	case *raw.Null:
+		w.WriteString("null")
+	case *raw.Substitution:
+		if w.opts.JSON {
+			return &common.BadValue{Reason: fmt.Sprintf("cannot render substitution %s as JSON", val.String()), Origin: val.Origin()}
+		}
+		w.WriteString("${")
+		if val.Optional {
+			w.WriteByte('?')
+		}
+		w.WriteString(w.path(val.Path.AsPath()))
+		w.WriteByte('}')
+	case *raw.Concat:
+		if w.opts.JSON {
+			return &common.BadValue{Reason: fmt.Sprintf("cannot render concatenation %s as JSON", val.String()), Origin: val.Origin()}
+		}
+		for i, item := range val.Values {
+			if i > 0 && val.Spaces[i-1] != nil {
+				w.WriteString(*val.Spaces[i-1])
+			}
+			if err := w.rawValue(item, depth); err != nil {
+				return err
+			}
+		}
+	default:
+		return &common.BadValue{Reason: fmt.Sprintf("cannot render value of type %T", v)}
+	}
+	return nil
+}
+
+func renderInclusion(inc raw.Inclusion) string {
+	target := Quote(inc.Path)
+	if inc.Location != nil {
+		target = fmt.Sprintf("%s(%s)", inc.Location.String(), target)
+	}
+	if inc.Required {
+		target = fmt.Sprintf("required(%s)", target)
+	}
+	return "include " + target
+}
+
+func (w *writer) key(key string) string {
+	if w.opts.JSON {
+		return Quote(key)
+	}
+	return QuoteKey(key)
+}
+
+func (w *writer) path(parts []string) string {
+	if w.opts.JSON {
+		return Quote(strings.Join(parts, "."))
+	}
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = QuoteKey(part)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// QuoteKey returns key as a single HOCON path segment, quoting it unless it
+// consists only of letters, digits, '-' and '_'. Keys starting with
+// "include" are quoted too, as the parser reads them as include statements.
+func QuoteKey(key string) string {
+	if key == "" || strings.HasPrefix(key, "include") {
+		return Quote(key)
+	}
+	for _, r := range key {
+		isWord := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+		if !isWord {
+			return Quote(key)
+		}
+	}
+	return key
+}
+
+// Quote returns s as a JSON string literal, which is also valid HOCON.
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func formatNumber(n raw.Number) string {
+	switch num := n.(type) {
+	case *raw.PosInt:
+		return strconv.FormatUint(num.Val, 10)
+	case *raw.NegInt:
+		return strconv.FormatInt(num.Val, 10)
+	case *raw.Float:
+		if math.IsInf(num.Val, 0) || math.IsNaN(num.Val) {
+			return Quote(strconv.FormatFloat(num.Val, 'g', -1, 64))
+		}
+		s := strconv.FormatFloat(num.Val, 'f', -1, 64)
+		if len(s) > 21 {
+			s = strconv.FormatFloat(num.Val, 'g', -1, 64)
+		}
+		// Keep a fraction so the value is read back as a float.
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	default:
+		return fmt.Sprintf("%v", n)
+	}
+}